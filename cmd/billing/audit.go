@@ -0,0 +1,54 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package billing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the billing status changes made from this machine",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		changes, err := data.BillingChanges(limit)
+		if err != nil {
+			return err
+		}
+
+		return cmdutil.Print(os.Stdout, changes, func(out io.Writer) error {
+			if len(changes) == 0 {
+				fmt.Fprintln(os.Stderr, "no billing changes recorded")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "When\tRequisition\tFrom\tTo\tResult")
+			for _, c := range changes {
+				result := "ok"
+				switch {
+				case c.Pending:
+					result = "outcome unknown, check the requisition"
+				case c.Error != "":
+					result = c.Error
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(c.ChangedAt), c.Requisition, c.From, c.To, result)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	auditCmd.Flags().Int("limit", 50, "number of most recent changes to show")
+}
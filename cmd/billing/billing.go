@@ -0,0 +1,95 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package billing
+
+import (
+	"context"
+	"fmt"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"strings"
+	"time"
+
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var BillingCmd = &cobra.Command{
+	Use:   "billing",
+	Short: "Track and move requisitions through billing",
+}
+
+func init() {
+	BillingCmd.AddCommand(listCmd)
+	BillingCmd.AddCommand(summaryCmd)
+	BillingCmd.AddCommand(moveCmd)
+	BillingCmd.AddCommand(auditCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+	cmdutil.AddFieldFlags(summaryCmd)
+	cmdutil.AddFieldFlags(auditCmd)
+}
+
+// adds the date range flags shared by the commands that list requisitions
+func addRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", "start of requisition date range (YYYY-MM-DD, MM-DD-YYYY, 7d, yesterday, this-month..)")
+	cmd.Flags().String("until", "", "end of requisition date range, defaults to today")
+}
+
+func rangeFromFlags(cmd *cobra.Command) (utils.DateRange, error) {
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+	return utils.ParseDateRange(since, until, time.Now())
+}
+
+// parses a billing status given on the command line, only known ones are
+// accepted so a typo can't create a new status
+func parseStatus(s string) (models.BillingStatus, error) {
+	status := models.BillingStatus(strings.ToLower(strings.TrimSpace(s)))
+	if !status.Known() {
+		names := make([]string, 0, len(models.BillingStatuses()))
+		for _, known := range models.BillingStatuses() {
+			names = append(names, string(known))
+		}
+		return "", fmt.Errorf("unknown billing status %q, use one of %s", s, strings.Join(names, ", "))
+	}
+	return status, nil
+}
+
+// requisitions in the date range, filtered to the given billing statuses
+// when there are any
+func requisitionsWithStatus(ctx context.Context, client *models.Client, dates utils.DateRange, statuses ...models.BillingStatus) ([]models.ProjectRequisition, error) {
+	var reqs []models.ProjectRequisition
+	err := utils.EachProjectRequisition(ctx, client, utils.RequisitionQuery{Range: dates}, func(r models.ProjectRequisition) error {
+		if len(statuses) == 0 {
+			reqs = append(reqs, r)
+			return nil
+		}
+		for _, s := range statuses {
+			if r.BillingStatus == s {
+				reqs = append(reqs, r)
+				break
+			}
+		}
+		return nil
+	})
+	return reqs, err
+}
+
+// whole days since t
+func ageDays(t, now time.Time) int {
+	if t.IsZero() {
+		return 0
+	}
+	return int(now.Sub(t).Hours() / 24)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package billing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List requisitions in the date range by billing status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		statusFlags, _ := cmd.Flags().GetStringSlice("status")
+
+		var statuses []models.BillingStatus
+		for _, s := range statusFlags {
+			status, err := parseStatus(s)
+			if err != nil {
+				return err
+			}
+			statuses = append(statuses, status)
+		}
+
+		dates, err := rangeFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		reqs, err := requisitionsWithStatus(context.Background(), client, dates, statuses...)
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].Identifier < reqs[j].Identifier })
+
+		return cmdutil.Print(os.Stdout, reqs, func(out io.Writer) error {
+			if len(reqs) == 0 {
+				fmt.Fprintf(os.Stderr, "no requisitions from %s\n", dates)
+				return nil
+			}
+
+			now := time.Now()
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "Identifier\tBilling\tAge (days)\tCreated")
+			for _, r := range reqs {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", r.Identifier, r.BillingStatus, ageDays(r.CreatedAt.Time, now), formatTime(r.CreatedAt.Time))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	listCmd.Flags().StringSlice("status", nil, "only list these billing statuses, e.g. pending,denied")
+	addRangeFlags(listCmd)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move <status> [identifier...]",
+	Short: "Move requisitions to a new billing status",
+	Long: `Move requisitions to a new billing status.
+
+Requisitions come from the arguments, from --from-file (one identifier per
+line) and/or from every requisition in --since/--until whose billing status
+is one of --from-status. The moves are listed and confirmed before anything
+is sent. each status is checked again right before its move, and every
+attempt is written to the local billing audit before it's sent, see
+'oah billing audit'.`,
+	Example: "  oah billing move submitted --from-status pending --since last-month",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromFile, _ := cmd.Flags().GetString("from-file")
+		fromStatus, _ := cmd.Flags().GetStringSlice("from-status")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		to, err := parseStatus(args[0])
+		if err != nil {
+			return err
+		}
+
+		var from []models.BillingStatus
+		for _, s := range fromStatus {
+			status, err := parseStatus(s)
+			if err != nil {
+				return err
+			}
+			from = append(from, status)
+		}
+
+		identifiers := append([]string{}, args[1:]...)
+		if fromFile != "" {
+			ids, err := cmdutil.ReadIdentifiers(fromFile)
+			if err != nil {
+				return err
+			}
+			identifiers = append(identifiers, ids...)
+		}
+		identifiers = cmdutil.Dedupe(identifiers)
+
+		if len(identifiers) == 0 && len(from) == 0 {
+			return fmt.Errorf("no requisitions to move, pass identifiers, --from-file or --from-status")
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		// the current status of each requisition, for the preview and audit
+		current := map[string]models.BillingStatus{}
+		var order []string
+
+		for _, id := range identifiers {
+			var res models.RequisitionResponse
+			if err := utils.GetRequisition(ctx, client, id, &res); err != nil {
+				if utils.IsNotFound(err) {
+					return fmt.Errorf("requisition %s not found", id)
+				}
+				return err
+			}
+			current[id] = res.Requisition.BillingStatus
+			order = append(order, id)
+		}
+
+		if len(from) > 0 {
+			dates, err := rangeFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			reqs, err := requisitionsWithStatus(ctx, client, dates, from...)
+			if err != nil {
+				return err
+			}
+			for _, r := range reqs {
+				if _, ok := current[r.Identifier]; !ok {
+					current[r.Identifier] = r.BillingStatus
+					order = append(order, r.Identifier)
+				}
+			}
+		}
+
+		var moves []string
+		for _, id := range order {
+			if current[id] != to {
+				moves = append(moves, id)
+			}
+		}
+		if already := len(order) - len(moves); already > 0 {
+			fmt.Fprintf(os.Stderr, "%d already %s\n", already, to)
+		}
+		if len(moves) == 0 {
+			fmt.Fprintln(os.Stderr, "nothing to move")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+		for _, id := range moves {
+			fmt.Fprintf(w, "%s\t%s\t→ %s\n", id, current[id], to)
+		}
+		w.Flush()
+
+		if !yes {
+			ok, err := cmdutil.Confirm(fmt.Sprintf("move %d requisitions to %s?", len(moves), to))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("move cancelled")
+			}
+		}
+
+		failed := 0
+		for _, id := range moves {
+			if err := moveRequisition(ctx, client, id, current[id], to); err != nil {
+				var audit *auditError
+				if errors.As(err, &audit) {
+					return err
+				}
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "moved %s to %s\n", id, to)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d moves failed", failed, len(moves))
+		}
+		return nil
+	},
+}
+
+// the audit couldn't be written, the move stops rather than make changes
+// the audit doesn't know about
+type auditError struct{ err error }
+
+func (e *auditError) Error() string { return e.err.Error() }
+
+// moves one requisition after checking its status is still the previewed
+// one. the change is in the audit as pending before it's sent and gets its
+// outcome after.
+func moveRequisition(ctx context.Context, client *models.Client, id string, previewed, to models.BillingStatus) error {
+	var latest models.RequisitionResponse
+	if err := utils.GetRequisition(ctx, client, id, &latest); err != nil {
+		return err
+	}
+	if status := latest.Requisition.BillingStatus; status != previewed {
+		err := fmt.Errorf("billing status changed to %s since the preview, not moved", status)
+		change := models.BillingChange{Requisition: id, From: status, To: to, Error: err.Error(), ChangedAt: time.Now()}
+		if auditErr := data.RecordBillingChange(change); auditErr != nil {
+			return &auditError{auditErr}
+		}
+		return err
+	}
+
+	change := models.BillingChange{Requisition: id, From: previewed, To: to, ChangedAt: time.Now()}
+	auditId, err := data.StartBillingChange(change)
+	if err != nil {
+		return &auditError{err}
+	}
+
+	// same guard as requisitions update against a change after the check
+	fields := map[string]any{"billing_status": to}
+	if updatedAt := latest.Requisition.UpdatedAt; !updatedAt.IsZero() {
+		err = utils.UpdateRequisitionIfUnmodified(ctx, client, id, fields, updatedAt.Time, nil)
+		if utils.IsStale(err) {
+			err = fmt.Errorf("modified by someone else after its status was checked, not moved")
+		}
+	} else {
+		err = utils.UpdateRequisition(ctx, client, id, fields, nil)
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if auditErr := data.FinishBillingChange(auditId, errMsg, time.Now()); auditErr != nil {
+		return &auditError{auditErr}
+	}
+	return err
+}
+
+func init() {
+	moveCmd.Flags().String("from-file", "", "file with one requisition identifier per line")
+	moveCmd.Flags().StringSlice("from-status", nil, "move every requisition in the date range with these billing statuses")
+	moveCmd.Flags().BoolP("yes", "y", false, "don't ask for confirmation")
+	addRangeFlags(moveCmd)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package billing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/spf13/cobra"
+)
+
+// counts and ages of the requisitions in one billing status
+type statusSummary struct {
+	Status     models.BillingStatus `json:"status"`
+	Count      int                  `json:"count"`
+	AverageAge float64              `json:"averageAgeDays"`
+	OldestAge  int                  `json:"oldestAgeDays"`
+	Oldest     string               `json:"oldest"`
+}
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Count requisitions and their age in each billing status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dates, err := rangeFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		reqs, err := requisitionsWithStatus(context.Background(), client, dates)
+		if err != nil {
+			return err
+		}
+
+		summaries := summarize(reqs, time.Now())
+
+		return cmdutil.Print(os.Stdout, summaries, func(out io.Writer) error {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "requisitions from %s\n\n", dates)
+			fmt.Fprintln(w, "Billing\tCount\tAvg Age (days)\tOldest (days)\tOldest Requisition")
+			total := 0
+			for _, s := range summaries {
+				fmt.Fprintf(w, "%s\t%d\t%.1f\t%d\t%s\n", s.Status, s.Count, s.AverageAge, s.OldestAge, s.Oldest)
+				total += s.Count
+			}
+			fmt.Fprintf(w, "total\t%d\t\t\t\n", total)
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	addRangeFlags(summaryCmd)
+}
+
+// groups requisitions by billing status, known statuses come first in
+// workflow order and any the api added later follow alphabetically
+func summarize(reqs []models.ProjectRequisition, now time.Time) []statusSummary {
+	byStatus := map[models.BillingStatus]*statusSummary{}
+	totalAge := map[models.BillingStatus]int{}
+
+	for _, r := range reqs {
+		s, ok := byStatus[r.BillingStatus]
+		if !ok {
+			s = &statusSummary{Status: r.BillingStatus}
+			byStatus[r.BillingStatus] = s
+		}
+
+		age := ageDays(r.CreatedAt.Time, now)
+		s.Count++
+		totalAge[r.BillingStatus] += age
+		if s.Oldest == "" || age > s.OldestAge {
+			s.OldestAge = age
+			s.Oldest = r.Identifier
+		}
+	}
+
+	var summaries []statusSummary
+	for _, status := range models.BillingStatuses() {
+		if s, ok := byStatus[status]; ok {
+			summaries = append(summaries, *s)
+			delete(byStatus, status)
+		}
+	}
+
+	var unknown []statusSummary
+	for _, s := range byStatus {
+		unknown = append(unknown, *s)
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Status < unknown[j].Status })
+	summaries = append(summaries, unknown...)
+
+	for i := range summaries {
+		s := &summaries[i]
+		s.AverageAge = float64(totalAge[s.Status]) / float64(s.Count)
+	}
+	return summaries
+}
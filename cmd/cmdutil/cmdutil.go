@@ -0,0 +1,278 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package cmdutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+)
+
+// GlobalOptions holds the values of the root command's persistent flags
+type GlobalOptions struct {
+	BaseURL       string
+	APIVersion    string
+	Verbose       bool
+	Trace         bool
+	LogFile       string
+	MaxAttempts   int
+	RetryDeadline time.Duration
+	Record        string
+	Replay        string
+	Output        string
+	Fields        []string
+	Template      string
+	Profile       string
+}
+
+// Global is filled in by cobra when the root command parses its flags
+var Global GlobalOptions
+
+var netTransport = &http.Transport{
+	Dial: (&net.Dialer{
+		Timeout: 5 * time.Second,
+	}).Dial,
+	TLSHandshakeTimeout: 5 * time.Second,
+}
+
+// NewClient builds an api client from the stored params. global flags take
+// precedence over the stored values, which take precedence over defaults.
+func NewClient(params models.DbRow) (*models.Client, error) {
+	transport, err := transport()
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := Logger()
+	if err != nil {
+		return nil, err
+	}
+
+	level := utils.ParseLogLevel(Global.Verbose, Global.Trace)
+	if level != utils.LogOff {
+		transport = &utils.LoggingTransport{Base: transport, Logger: logger, Level: level, Secrets: []string{params.Auth}}
+	}
+
+	httpClient := &http.Client{Timeout: time.Second * 10, Transport: transport}
+	client := models.NewClient(httpClient, params.OrgId, params.ProjTempId, "Bearer "+params.Auth)
+
+	client.BaseURL = firstNonEmpty(Global.BaseURL, params.BaseURL, client.BaseURL)
+	client.APIVersion = firstNonEmpty(Global.APIVersion, params.APIVersion, client.APIVersion)
+
+	if err := models.ValidateBaseURL(client.BaseURL); err != nil {
+		return nil, err
+	}
+
+	if Global.MaxAttempts > 0 {
+		client.Retry.MaxAttempts = Global.MaxAttempts
+	}
+	if Global.RetryDeadline > 0 {
+		client.Retry.MaxElapsed = Global.RetryDeadline
+	}
+
+	if params.RateLimit > 0 {
+		client.Limiter = sharedLimiter(params.RateLimit, params.RateBurst)
+	}
+
+	if level != utils.LogOff {
+		client.Logger = logger
+	}
+
+	return client, nil
+}
+
+var (
+	loggerOnce sync.Once
+	logger     *log.Logger
+	loggerErr  error
+)
+
+// Logger returns the logger for -v/--trace output, writing to --log-file
+// when one was given and to stderr otherwise
+func Logger() (*log.Logger, error) {
+	loggerOnce.Do(func() {
+		out := io.Writer(os.Stderr)
+		if Global.LogFile != "" {
+			f, err := os.OpenFile(Global.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				loggerErr = fmt.Errorf("opening log file: %w", err)
+				return
+			}
+			out = f
+		}
+		logger = log.New(out, "oah: ", log.LstdFlags)
+	})
+
+	return logger, loggerErr
+}
+
+// LogToFileByDefault sends log output to path unless --log-file was given,
+// full screen commands call it so logging doesn't draw over the tui
+func LogToFileByDefault(path string) {
+	if Global.LogFile == "" && (Global.Verbose || Global.Trace) {
+		Global.LogFile = path
+	}
+}
+
+// record/replay transports are created once so every client in the
+// process writes to, or reads from, the same cassette
+var (
+	transportOnce sync.Once
+	sharedRT      http.RoundTripper
+	transportErr  error
+)
+
+// picks the round tripper for --record/--replay, or the plain network one
+func transport() (http.RoundTripper, error) {
+	transportOnce.Do(func() {
+		switch {
+		case Global.Record != "" && Global.Replay != "":
+			transportErr = fmt.Errorf("--record and --replay can't be used together")
+		case Global.Replay != "":
+			cassette, err := utils.LoadCassette(Global.Replay)
+			if err != nil {
+				transportErr = err
+				return
+			}
+			sharedRT = utils.NewReplayTransport(cassette)
+		case Global.Record != "":
+			sharedRT = utils.NewRecordingTransport(netTransport, Global.Record)
+		default:
+			sharedRT = netTransport
+		}
+	})
+
+	return sharedRT, transportErr
+}
+
+// every client built in this process with the same settings shares one
+// limiter, so concurrent commands and tui requests stay under one quota
+var (
+	limitersMu sync.Mutex
+	limiters   = map[[2]float64]*utils.RateLimiter{}
+)
+
+func sharedLimiter(rps float64, burst int) *utils.RateLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	key := [2]float64{rps, float64(burst)}
+	if l, ok := limiters[key]; ok {
+		return l
+	}
+	l := utils.NewRateLimiter(rps, burst)
+	limiters[key] = l
+	return l
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// StoredClient builds an api client from the params saved by 'oah setup',
+// with any OAH_* environment variables taking precedence over them
+func StoredClient() (*models.Client, error) {
+	env, err := ParamsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := data.GetValues()
+	if err != nil && env["auth"] == nil {
+		return nil, fmt.Errorf("reading stored params, run 'oah setup' or set OAH_TOKEN first: %w", err)
+	}
+
+	return NewClient(ApplyParams(params, env))
+}
+
+// StoredParams is the active profile's params with the environment
+// overrides applied, for commands that can work without a token. params
+// that were never stored are left empty.
+func StoredParams() (models.DbRow, error) {
+	// a missing profile only means nothing was stored yet
+	params, _ := data.GetValues()
+	return WithEnv(params)
+}
+
+// PrintJSON writes v as indented json
+func PrintJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Confirm asks a yes/no question on stderr and reads the answer from
+// stdin, anything but y/yes counts as no
+func Confirm(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// ReadIdentifiers reads identifiers one per line, blank lines and #
+// comments are skipped
+func ReadIdentifiers(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	return ids, scanner.Err()
+}
+
+// Dedupe drops repeated identifiers, keeping the first of each in order
+func Dedupe(ids []string) []string {
+	seen := map[string]bool{}
+	out := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
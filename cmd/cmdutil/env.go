@@ -0,0 +1,89 @@
+package cmdutil
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// environment variables that override stored params, keyed by the params
+// column they stand in for. flags given to 'oah setup' override these.
+var EnvParams = []struct {
+	Name  string
+	Param string
+}{
+	{"OAH_TOKEN", "auth"},
+	{"OAH_ORG_ID", "orgId"},
+	{"OAH_PROJECT_TEMPLATE_ID", "projTempId"},
+}
+
+// ParamsFromEnv returns the params set in the environment, validated and
+// converted like the values entered in setup
+func ParamsFromEnv() (map[string]any, error) {
+	values := map[string]any{}
+	for _, env := range EnvParams {
+		raw, ok := os.LookupEnv(env.Name)
+		if !ok || raw == "" {
+			continue
+		}
+		value, err := data.ParseParam(env.Param, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", env.Name, err)
+		}
+		values[env.Param] = value
+	}
+	return values, nil
+}
+
+// WithEnv is params with the environment overrides applied
+func WithEnv(params models.DbRow) (models.DbRow, error) {
+	env, err := ParamsFromEnv()
+	if err != nil {
+		return params, err
+	}
+	return ApplyParams(params, env), nil
+}
+
+// ApplyParams overwrites the fields of params that have a value in values
+func ApplyParams(params models.DbRow, values map[string]any) models.DbRow {
+	if v, ok := values["auth"].(string); ok {
+		params.Auth = v
+	}
+	if v, ok := values["orgId"].(models.OrganizationId); ok {
+		params.OrgId = v
+	}
+	if v, ok := values["projTempId"].(models.ProjectTemplateId); ok {
+		params.ProjTempId = v
+	}
+	return params
+}
+
+// MaskToken keeps the last four characters of a token so it can be
+// recognized without being shown
+func MaskToken(token string) string {
+	if len(token) <= 8 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
+// ProfileEnv picks the profile when --profile isn't given
+const ProfileEnv = "OAH_PROFILE"
+
+// SelectProfile makes --profile, or else OAH_PROFILE, the active profile
+// for this run. with neither the remembered default is used.
+func SelectProfile() error {
+	name := firstNonEmpty(Global.Profile, os.Getenv(ProfileEnv))
+	if name == "" {
+		return nil
+	}
+	if err := data.SelectProfile(name); err != nil {
+		if Global.Profile == "" {
+			return fmt.Errorf("%s: %w", ProfileEnv, err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// functions available to --template on top of the text/template builtins
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// AddFieldFlags registers --fields and --template on a read command that
+// prints through Print, commands that don't print records don't take them
+func AddFieldFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&Global.Fields, "fields", nil, "only print these fields, using their json names, e.g. identifier,billing_status,createdAt")
+	cmd.Flags().StringVar(&Global.Template, "template", "", "print each record with a go template, e.g. '{{.Identifier}} {{.BillingStatus}}'")
+}
+
+// parses --template, CheckOutput calls it so a bad template fails early
+func parseTemplate() (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(Global.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// runs --template once per record of v, each followed by a newline
+func printTemplate(w io.Writer, v any) error {
+	tmpl, err := parseTemplate()
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	items := []reflect.Value{rv}
+	if rv.Kind() == reflect.Slice {
+		items = items[:0]
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i))
+		}
+	}
+
+	for _, item := range items {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, item.Interface()); err != nil {
+			return templateError(err, item.Type())
+		}
+		if b.Len() == 0 || b.Bytes()[b.Len()-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		if _, err := w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var missingField = regexp.MustCompile(`can't evaluate field (\w+)`)
+
+// adds a suggestion when the template used a field the type doesn't have
+func templateError(err error, t reflect.Type) error {
+	m := missingField.FindStringSubmatch(err.Error())
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if m == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("--template: %w", err)
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			names = append(names, f.Name)
+		}
+	}
+	return fmt.Errorf("--template: %s has no field %s%s", t.Name(), m[1], didYouMean(m[1], names))
+}
+
+// the fields --fields can select on the records of v. these are the json
+// names of the struct fields, nested ones joined with dots, plus anything
+// the api sent that the models don't know about yet.
+func selectableFields(v any, generic any) (fields map[string]bool, mapPrefixes []string) {
+	fields = map[string]bool{}
+
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer) {
+		t = t.Elem()
+	}
+	mapPrefixes = structFields(t, "", fields, 0)
+
+	for _, r := range records(generic) {
+		row := map[string]string{}
+		flattenRow("", r, row)
+		for k := range row {
+			for {
+				fields[k] = true
+				i := strings.LastIndex(k, ".")
+				if i < 0 {
+					break
+				}
+				k = k[:i]
+			}
+		}
+	}
+	return fields, mapPrefixes
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func structFields(t reflect.Type, prefix string, fields map[string]bool, depth int) (mapPrefixes []string) {
+	if t == nil || t.Kind() != reflect.Struct || t == timeType || depth > 4 {
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fields[path] = true
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			mapPrefixes = append(mapPrefixes, structFields(ft, path, fields, depth+1)...)
+		case reflect.Map:
+			mapPrefixes = append(mapPrefixes, path+".")
+		}
+	}
+	return mapPrefixes
+}
+
+// checks every --fields name against the records being printed
+func checkFields(v any, generic any) error {
+	fields, mapPrefixes := selectableFields(v, generic)
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, f := range Global.Fields {
+		if fields[f] || hasAnyPrefix(f, mapPrefixes) {
+			continue
+		}
+		return fmt.Errorf("unknown field %q in --fields%s", f, didYouMean(f, names))
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// keeps only the --fields of each record, in json, yaml and jsonl nested
+// fields stay nested
+func projectFields(generic any) any {
+	project := func(r any) any {
+		out := map[string]any{}
+		for _, f := range Global.Fields {
+			setField(out, f, fieldValue(r, f))
+		}
+		return out
+	}
+
+	if list, ok := generic.([]any); ok {
+		projected := make([]any, len(list))
+		for i, r := range list {
+			projected[i] = project(r)
+		}
+		return projected
+	}
+	return project(generic)
+}
+
+// looks up a dotted field in a decoded json object
+func fieldValue(v any, path string) any {
+	for _, part := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+func setField(m map[string]any, path string, value any) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// table of just the --fields, used in place of a command's own table
+func printFieldsTable(out io.Writer, generic any) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(Global.Fields, "\t"))
+
+	row := make([]string, len(Global.Fields))
+	for _, r := range records(generic) {
+		for i, f := range Global.Fields {
+			row[i] = cell(fieldValue(r, f))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// suggests the closest of names to s, or nothing if none is close
+func didYouMean(s string, names []string) string {
+	best, bestDist := "", -1
+	for _, name := range names {
+		d := editDistance(strings.ToLower(s), strings.ToLower(name))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+
+	if bestDist < 0 || bestDist > len(s)/3+1 {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,270 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormats are the values accepted by --output
+var OutputFormats = []string{"table", "json", "jsonl", "yaml", "csv", "tsv"}
+
+// CheckOutput reports a bad --output, --fields or --template before a
+// command does any work
+func CheckOutput() error {
+	if Global.Template != "" {
+		if len(Global.Fields) > 0 {
+			return fmt.Errorf("--fields and --template can't be used together")
+		}
+		_, err := parseTemplate()
+		return err
+	}
+
+	if Global.Output == "" {
+		return nil
+	}
+	for _, f := range OutputFormats {
+		if Global.Output == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output %q, use one of %s", Global.Output, strings.Join(OutputFormats, ", "))
+}
+
+// OutputFormat is the --output format, when it wasn't given it's table on
+// a terminal and json when stdout is piped
+func OutputFormat() string {
+	if Global.Output != "" {
+		return Global.Output
+	}
+	if IsTerminal(os.Stdout) {
+		return "table"
+	}
+	return "json"
+}
+
+// Print writes v in the --output format. table writes the human readable
+// table, every other format is built from v. slices give one record per
+// element for jsonl, csv and tsv. --template runs against v itself and
+// --fields picks columns out of every format.
+func Print(w io.Writer, v any, table func(w io.Writer) error) error {
+	if Global.Template != "" {
+		return printTemplate(w, v)
+	}
+
+	// a nil slice is an empty list rather than null
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = []any{}
+	}
+
+	if OutputFormat() == "table" && len(Global.Fields) == 0 {
+		return table(w)
+	}
+
+	// every other format works off the same json the api speaks, so field
+	// names match across formats and object keys come out sorted
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	if len(Global.Fields) > 0 {
+		if err := checkFields(v, generic); err != nil {
+			return err
+		}
+		switch OutputFormat() {
+		case "table":
+			return printFieldsTable(w, generic)
+		case "csv":
+			return printDelimited(w, generic, ',')
+		case "tsv":
+			return printDelimited(w, generic, '\t')
+		}
+		generic = projectFields(generic)
+	}
+
+	switch OutputFormat() {
+	case "json":
+		return PrintJSON(w, generic)
+	case "jsonl":
+		return printJSONLines(w, generic)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(yamlValue(generic)); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "csv":
+		return printDelimited(w, generic, ',')
+	case "tsv":
+		return printDelimited(w, generic, '\t')
+	}
+	return CheckOutput()
+}
+
+// round trips v through json so struct tags, custom marshalers and Extra
+// fields are applied, numbers are kept exactly as they were
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// yaml would quote json.Number as a string, so numbers are converted back
+func yamlValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = yamlValue(val)
+		}
+	case []any:
+		for i := range v {
+			v[i] = yamlValue(v[i])
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
+
+func records(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+	if v == nil {
+		return nil
+	}
+	return []any{v}
+}
+
+func printJSONLines(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records(v) {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes one row per record with a header of every field seen, nested
+// objects become dotted columns and lists are written as json. with
+// --fields only those columns are written, in the order given.
+func printDelimited(w io.Writer, v any, comma rune) error {
+	var rows []map[string]string
+	columns := map[string]bool{}
+
+	for _, r := range records(v) {
+		row := map[string]string{}
+		if len(Global.Fields) > 0 {
+			for _, f := range Global.Fields {
+				row[f] = cell(fieldValue(r, f))
+			}
+		} else {
+			flattenRow("", r, row)
+		}
+		for k := range row {
+			columns[k] = true
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	header := Global.Fields
+	if len(header) == 0 {
+		header = make([]string, 0, len(columns))
+		for k := range columns {
+			header = append(header, k)
+		}
+		sort.Strings(header)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	line := make([]string, len(header))
+	for _, row := range rows {
+		for i, k := range header {
+			line[i] = row[k]
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func flattenRow(prefix string, v any, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = ""
+		}
+		for k, val := range v {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			flattenRow(k, val, out)
+		}
+	default:
+		if prefix == "" {
+			prefix = "value"
+		}
+		out[prefix] = cell(v)
+	}
+}
+
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case []any:
+		if len(v) == 0 {
+			return ""
+		}
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/spf13/cobra"
+)
+
+// one stored parameter as shown by config list
+type entry struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	OverrideBy string `json:"overriddenBy,omitempty"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show every stored parameter, the token is masked",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params, err := data.GetValues()
+		if err != nil {
+			return fmt.Errorf("reading stored params, run 'oah setup' first: %w", err)
+		}
+
+		overrides := map[string]string{}
+		for _, env := range cmdutil.EnvParams {
+			if os.Getenv(env.Name) != "" {
+				overrides[env.Param] = env.Name
+			}
+		}
+
+		entries := make([]entry, 0, len(data.ParamKeys))
+		for _, key := range data.ParamKeys {
+			value := paramValue(params, key)
+			if key == "auth" && value != "" {
+				value = cmdutil.MaskToken(value)
+			}
+			entries = append(entries, entry{Key: key, Value: value, OverrideBy: overrides[key]})
+		}
+
+		return cmdutil.Print(os.Stdout, entries, func(out io.Writer) error {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			for _, e := range entries {
+				value := e.Value
+				if e.OverrideBy != "" {
+					value += "  (overridden by " + e.OverrideBy + ")"
+				}
+				fmt.Fprintf(w, "%s\t%s\n", e.Key, value)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print one stored parameter",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := paramKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		params, err := data.GetValues()
+		if err != nil {
+			return fmt.Errorf("reading stored params, run 'oah setup' first: %w", err)
+		}
+
+		fmt.Println(paramValue(params, key))
+		return nil
+	},
+}
+
+var setCmd = &cobra.Command{
+	Use:     "set <key> <value>",
+	Short:   "Change one stored parameter",
+	Example: "  oah config set rateLimit 5\n  oah config set template 101",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := paramKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		value, err := data.ParseParam(key, args[1])
+		if err != nil {
+			return err
+		}
+
+		if err := data.InitParams(); err != nil {
+			return err
+		}
+		return data.UpdateX(key, value)
+	},
+}
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Clear one stored parameter so its default is used",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := paramKey(args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := data.InitParams(); err != nil {
+			return err
+		}
+		return data.UnsetX(key)
+	},
+}
+
+var pathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print where the parameters are stored",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := data.Path()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(path)
+		return nil
+	},
+}
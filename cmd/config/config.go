@@ -0,0 +1,85 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package config
+
+import (
+	"fmt"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"strconv"
+	"strings"
+
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "View and change the stored parameters",
+	Long: `View and change the parameters saved by 'oah setup'.
+
+Keys are the params columns: ` + strings.Join(data.ParamKeys, ", ") + `.
+token, org and template also work for auth, orgId and projTempId.`,
+}
+
+func init() {
+	ConfigCmd.AddCommand(listCmd)
+	ConfigCmd.AddCommand(getCmd)
+	ConfigCmd.AddCommand(setCmd)
+	ConfigCmd.AddCommand(unsetCmd)
+	ConfigCmd.AddCommand(pathCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+}
+
+// friendlier names for the params columns, matching the setup flags
+var keyAliases = map[string]string{
+	"token":    "auth",
+	"org":      "orgId",
+	"template": "projTempId",
+}
+
+// resolves a key given on the command line to its params column
+func paramKey(key string) (string, error) {
+	if alias, ok := keyAliases[key]; ok {
+		return alias, nil
+	}
+	for _, k := range data.ParamKeys {
+		if strings.EqualFold(k, key) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown key %q, use one of %s", key, strings.Join(data.ParamKeys, ", "))
+}
+
+// the stored value of key as text, empty when it isn't set
+func paramValue(params models.DbRow, key string) string {
+	switch key {
+	case "auth":
+		return params.Auth
+	case "orgId":
+		if params.OrgId > 0 {
+			return params.OrgId.String()
+		}
+	case "projTempId":
+		if params.ProjTempId > 0 {
+			return params.ProjTempId.String()
+		}
+	case "baseUrl":
+		return params.BaseURL
+	case "apiVersion":
+		return params.APIVersion
+	case "rateLimit":
+		if params.RateLimit > 0 {
+			return strconv.FormatFloat(params.RateLimit, 'f', -1, 64)
+		}
+	case "rateBurst":
+		if params.RateBurst > 0 {
+			return strconv.Itoa(params.RateBurst)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package patients
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var PatientsCmd = &cobra.Command{
+	Use:     "patients",
+	Aliases: []string{"patient"},
+	Short:   "Find patients and the requisitions they're on",
+}
+
+func init() {
+	PatientsCmd.AddCommand(searchCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(searchCmd)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package patients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search patients by name and date of birth",
+	Long: `Search patients by name and date of birth.
+
+Names, dates of birth and other phi are masked in the results unless
+--show-phi is given.`,
+	Example: "  oah patients search --last-name doe --dob 1980-04-12",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showPHI, _ := cmd.Flags().GetBool("show-phi")
+		firstName, _ := cmd.Flags().GetString("first-name")
+		lastName, _ := cmd.Flags().GetString("last-name")
+		dob, _ := cmd.Flags().GetString("dob")
+
+		query := utils.PatientQuery{FirstName: firstName, LastName: lastName}
+		if dob != "" {
+			t, err := utils.ParseDate(dob)
+			if err != nil {
+				return fmt.Errorf("--dob: %w", err)
+			}
+			query.DateOfBirth = t
+		}
+		if strings.TrimSpace(firstName+lastName) == "" && dob == "" {
+			return fmt.Errorf("give at least one of --first-name, --last-name or --dob")
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.Patients
+		if err := utils.SearchPatients(context.Background(), client, query, &res); err != nil {
+			return err
+		}
+
+		patients := res.Patients
+		if !showPHI {
+			for i := range patients {
+				patients[i] = utils.MaskPatient(patients[i])
+			}
+		}
+
+		sort.SliceStable(patients, func(i, j int) bool { return patients[i].Identifier < patients[j].Identifier })
+
+		return cmdutil.Print(os.Stdout, patients, func(w io.Writer) error {
+			return printPatients(w, patients)
+		})
+	},
+}
+
+func init() {
+	searchCmd.Flags().String("first-name", "", "patient first name")
+	searchCmd.Flags().String("last-name", "", "patient last name")
+	searchCmd.Flags().String("dob", "", "patient date of birth (YYYY-MM-DD, MM-DD-YYYY or MM/DD/YYYY)")
+	searchCmd.Flags().Bool("show-phi", false, "show names, dates of birth and mrns unmasked")
+}
+
+func printPatients(out io.Writer, patients []models.Patient) error {
+	if len(patients) == 0 {
+		fmt.Fprintln(out, "no matching patients")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Identifier\tName\tDate of Birth\tSex\tMRN\tRequisitions")
+	for _, p := range patients {
+		name := strings.TrimSpace(p.FirstName + " " + p.LastName)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Identifier, name, p.DateOfBirth, p.Sex, p.MRN, strings.Join(p.Requisitions, ", "))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,169 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package profile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/spf13/cobra"
+)
+
+// one profile as shown by profile list, the token is left out
+type entry struct {
+	Name              string                   `json:"name"`
+	Active            bool                     `json:"active"`
+	Default           bool                     `json:"default"`
+	OrganizationId    models.OrganizationId    `json:"organizationId"`
+	ProjectTemplateId models.ProjectTemplateId `json:"projectTemplateId"`
+	BaseURL           string                   `json:"baseUrl"`
+	HasToken          bool                     `json:"hasToken"`
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, the active one is marked with *",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profiles, err := data.Profiles()
+		if err != nil {
+			return err
+		}
+		active, err := data.ActiveProfile()
+		if err != nil {
+			return err
+		}
+		remembered, err := data.RememberedProfile()
+		if err != nil {
+			return err
+		}
+
+		entries := make([]entry, 0, len(profiles))
+		for _, p := range profiles {
+			entries = append(entries, entry{
+				Name:              p.Profile,
+				Active:            p.Profile == active,
+				Default:           p.Profile == remembered,
+				OrganizationId:    p.OrgId,
+				ProjectTemplateId: p.ProjTempId,
+				BaseURL:           p.BaseURL,
+				HasToken:          p.Auth != "",
+			})
+		}
+
+		return cmdutil.Print(os.Stdout, entries, func(out io.Writer) error {
+			if len(entries) == 0 {
+				fmt.Fprintln(os.Stderr, "no profiles, create one with 'oah setup' or 'oah profile create'")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "\tName\tOrg Id\tProj. Temp. Id\tBase URL\tDefault")
+			for _, e := range entries {
+				marker, isDefault := "", ""
+				if e.Active {
+					marker = "*"
+				}
+				if e.Default {
+					isDefault = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", marker, e.Name, idString(e.OrganizationId), idString(e.ProjectTemplateId), e.BaseURL, isDefault)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+// blank instead of 0 for ids that were never set
+func idString(id interface{ String() string }) string {
+	if s := id.String(); s != "0" {
+		return s
+	}
+	return ""
+}
+
+var useCmd = &cobra.Command{
+	Use:               "use <name>",
+	Short:             "Make a profile the default for commands run without --profile",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProfiles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := data.UseProfile(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "using profile %s\n", args[0])
+		return nil
+	},
+}
+
+var createCmd = &cobra.Command{
+	Use:     "create <name>",
+	Short:   "Add a profile, empty or copied from another",
+	Example: "  oah profile create staging --from default\n  oah --profile staging setup --org 12 --template 101",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+
+		if err := data.CreateProfile(args[0], from); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "created profile %s, fill it in with 'oah --profile %s setup'\n", args[0], args[0])
+		return nil
+	},
+}
+
+var deleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Remove a profile and its stored token",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProfiles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+		name := args[0]
+
+		if !yes {
+			ok, err := cmdutil.Confirm(fmt.Sprintf("delete profile %s?", name))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("delete cancelled")
+			}
+		}
+
+		if err := data.DeleteProfile(name); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "deleted profile %s\n", name)
+		return nil
+	},
+}
+
+var renameCmd = &cobra.Command{
+	Use:               "rename <name> <new name>",
+	Short:             "Rename a profile",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProfiles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := data.RenameProfile(args[0], args[1]); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "renamed profile %s to %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+func init() {
+	createCmd.Flags().String("from", "", "copy the params of this profile")
+	deleteCmd.Flags().BoolP("yes", "y", false, "don't ask for confirmation")
+}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package profile
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var ProfileCmd = &cobra.Command{
+	Use:     "profile",
+	Aliases: []string{"profiles"},
+	Short:   "Manage named sets of params, e.g. one per org",
+	Long: `Manage named sets of params, e.g. one per org or project template.
+
+Every command reads its params from the active profile. That's the one
+given with --profile or OAH_PROFILE, else the one picked with
+'oah profile use', else "` + data.DefaultProfile + `".`,
+}
+
+func init() {
+	ProfileCmd.AddCommand(listCmd)
+	ProfileCmd.AddCommand(useCmd)
+	ProfileCmd.AddCommand(createCmd)
+	ProfileCmd.AddCommand(deleteCmd)
+	ProfileCmd.AddCommand(renameCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+}
+
+// completes existing profile names for the first argument
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := data.ProfileNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
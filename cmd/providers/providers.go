@@ -0,0 +1,23 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package providers
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var ProvidersCmd = &cobra.Command{
+	Use:     "providers",
+	Aliases: []string{"provider"},
+	Short:   "Find ordering providers and the requisitions they ordered",
+}
+
+func init() {
+	ProvidersCmd.AddCommand(searchCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(searchCmd)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:     "search",
+	Short:   "Search ordering providers by name or NPI",
+	Example: "  oah providers search --npi 1234567893",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		firstName, _ := cmd.Flags().GetString("first-name")
+		lastName, _ := cmd.Flags().GetString("last-name")
+		npi, _ := cmd.Flags().GetString("npi")
+
+		if npi != "" && !models.ValidNPI(npi) {
+			return fmt.Errorf("--npi %q must be 10 digits", npi)
+		}
+		if strings.TrimSpace(firstName+lastName) == "" && npi == "" {
+			return fmt.Errorf("give at least one of --first-name, --last-name or --npi")
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.Providers
+		query := utils.ProviderQuery{FirstName: firstName, LastName: lastName, NPI: npi}
+		if err := utils.SearchProviders(context.Background(), client, query, &res); err != nil {
+			return err
+		}
+
+		providers := res.Providers
+		sort.SliceStable(providers, func(i, j int) bool { return providers[i].Identifier < providers[j].Identifier })
+
+		return cmdutil.Print(os.Stdout, providers, func(w io.Writer) error {
+			return printProviders(w, providers)
+		})
+	},
+}
+
+func init() {
+	searchCmd.Flags().String("first-name", "", "provider first name")
+	searchCmd.Flags().String("last-name", "", "provider last name")
+	searchCmd.Flags().String("npi", "", "provider NPI")
+}
+
+func printProviders(out io.Writer, providers []models.Provider) error {
+	if len(providers) == 0 {
+		fmt.Fprintln(out, "no matching providers")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Identifier\tName\tNPI\tRequisitions")
+	for _, p := range providers {
+		name := strings.TrimSpace(p.FirstName + " " + p.LastName)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Identifier, name, p.NPI, strings.Join(p.Requisitions, ", "))
+	}
+	return w.Flush()
+}
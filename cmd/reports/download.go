@@ -0,0 +1,374 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"text/template"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+// characters that can't safely go into a file name
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// values available to the --name template
+type nameData struct {
+	Identifier string
+	Date       string
+}
+
+// state of a single report download
+type downloadState int
+
+const (
+	waiting downloadState = iota
+	downloading
+	skipped
+	finished
+	failed
+)
+
+type download struct {
+	identifier string
+	path       string
+	state      downloadState
+	done       int64
+	total      int64
+	err        error
+}
+
+var downloadCmd = &cobra.Command{
+	Use:   "download [identifier...]",
+	Short: "Download final report pdfs for requisitions",
+	Long: `Download final report pdfs for requisitions.
+
+Requisitions come from the arguments, from --from-file (one identifier per
+line) and/or from every requisition in --since/--until whose reporting
+status is --status. Files that already exist with the same size are skipped.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fromFile, _ := cmd.Flags().GetString("from-file")
+		status, _ := cmd.Flags().GetString("status")
+		since, _ := cmd.Flags().GetString("since")
+		until, _ := cmd.Flags().GetString("until")
+		dir, _ := cmd.Flags().GetString("dir")
+		name, _ := cmd.Flags().GetString("name")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		nameTmpl, err := template.New("name").Option("missingkey=error").Parse(name)
+		if err != nil {
+			return fmt.Errorf("invalid --name template: %w", err)
+		}
+
+		progressView := cmdutil.IsTerminal(os.Stdout)
+		if progressView {
+			cmdutil.LogToFileByDefault("oah.log")
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+		// pdfs can take longer than the default request timeout, each
+		// download is bounded by its context instead
+		client.Http.Timeout = 0
+
+		// ctrl+c cancels the downloads in flight so their temp files are
+		// cleaned up, the progress view cancels it itself
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		identifiers := append([]string{}, args...)
+		if fromFile != "" {
+			ids, err := cmdutil.ReadIdentifiers(fromFile)
+			if err != nil {
+				return err
+			}
+			identifiers = append(identifiers, ids...)
+		}
+		if status != "" {
+			dates, err := utils.ParseDateRange(since, until, time.Now())
+			if err != nil {
+				return err
+			}
+			ids, err := identifiersWithStatus(ctx, client, dates, models.ReportingStatus(status))
+			if err != nil {
+				return err
+			}
+			identifiers = append(identifiers, ids...)
+		}
+
+		identifiers = cmdutil.Dedupe(identifiers)
+		if len(identifiers) == 0 {
+			return fmt.Errorf("no requisitions to download, pass identifiers, --from-file or --status")
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		downloads := make([]*download, len(identifiers))
+		// identifiers are made safe for file names, so different ones can
+		// still end up at the same path
+		targets := map[string]string{}
+		for i, id := range identifiers {
+			path, err := fileName(nameTmpl, dir, id)
+			if err != nil {
+				return err
+			}
+			if other, ok := targets[path]; ok {
+				return fmt.Errorf("%s and %s would both be saved to %s, use a --name that tells them apart", other, id, path)
+			}
+			targets[path] = id
+			downloads[i] = &download{identifier: id, path: path}
+		}
+
+		if progressView {
+			err = runWithProgress(ctx, cancel, client, downloads, concurrency)
+		} else {
+			err = runPlain(ctx, client, downloads, concurrency)
+		}
+		if err != nil {
+			return err
+		}
+
+		failures, completed := 0, 0
+		for _, d := range downloads {
+			switch d.state {
+			case failed:
+				failures++
+			case finished, skipped:
+				completed++
+			}
+		}
+		if ctx.Err() != nil && completed < len(downloads) {
+			return fmt.Errorf("interrupted, %d of %d reports downloaded", completed, len(downloads))
+		}
+		if failures > 0 {
+			return fmt.Errorf("%d of %d downloads failed", failures, len(downloads))
+		}
+		return nil
+	},
+}
+
+func init() {
+	downloadCmd.Flags().String("from-file", "", "file with one requisition identifier per line")
+	downloadCmd.Flags().String("status", "", "download every requisition in the date range with this reporting status, e.g. final")
+	downloadCmd.Flags().String("since", "", "start of the date range used with --status")
+	downloadCmd.Flags().String("until", "", "end of the date range used with --status")
+	downloadCmd.Flags().StringP("dir", "d", "reports", "directory to save reports in")
+	downloadCmd.Flags().String("name", "{{.Identifier}}.pdf", "file name template, fields are .Identifier and .Date")
+	downloadCmd.Flags().IntP("concurrency", "c", 4, "number of reports downloaded at the same time")
+}
+
+func identifiersWithStatus(ctx context.Context, client *models.Client, dates utils.DateRange, status models.ReportingStatus) ([]string, error) {
+	var ids []string
+	err := utils.EachProjectRequisition(ctx, client, utils.RequisitionQuery{Range: dates}, func(r models.ProjectRequisition) error {
+		if r.ReportingStatus == status {
+			ids = append(ids, r.Identifier)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+func fileName(tmpl *template.Template, dir, identifier string) (string, error) {
+	var b bytes.Buffer
+	data := nameData{
+		Identifier: unsafeName.ReplaceAllString(identifier, "_"),
+		Date:       time.Now().Format("2006-01-02"),
+	}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid --name template: %w", err)
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("--name template gave an empty file name for %s", identifier)
+	}
+	return filepath.Join(dir, b.String()), nil
+}
+
+// downloads with up to concurrency in flight, update is called with a copy
+// of a download whenever it changes. once ctx is done no new downloads are
+// started, it returns after every worker has stopped.
+func runDownloads(ctx context.Context, client *models.Client, downloads []*download, concurrency int, update func(i int, d download)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				d := downloads[i]
+				d.state = downloading
+				update(i, *d)
+
+				isSkipped, err := fetchReport(ctx, client, d, func(done, total int64) {
+					d.done, d.total = done, total
+					update(i, *d)
+				})
+				switch {
+				case err != nil:
+					d.state, d.err = failed, err
+				case isSkipped:
+					d.state = skipped
+				default:
+					d.state = finished
+				}
+				update(i, *d)
+			}
+		}()
+	}
+
+feed:
+	for i := range downloads {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// streams one report to disk through a temp file so an interrupted
+// download never looks complete. a file that already exists with the size
+// the server reports is left alone, the size is asked for first so it
+// isn't downloaded again just to find that out.
+func fetchReport(ctx context.Context, client *models.Client, d *download, progress func(done, total int64)) (bool, error) {
+	info, statErr := os.Stat(d.path)
+	if statErr == nil {
+		size, err := utils.ReportSize(ctx, client, d.identifier)
+		if err != nil {
+			return false, err
+		}
+		if size >= 0 && info.Size() == size {
+			progress(size, size)
+			return true, nil
+		}
+	}
+
+	res, err := utils.DownloadReport(ctx, client, d.identifier)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	// the server couldn't tell the size up front
+	if statErr == nil && res.ContentLength >= 0 && info.Size() == res.ContentLength {
+		progress(info.Size(), info.Size())
+		return true, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), ".oah-report-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	counter := &progressWriter{total: res.ContentLength, report: progress}
+	if _, err := io.Copy(io.MultiWriter(tmp, counter), res.Body); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	// temp files are created private
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return false, err
+	}
+
+	return false, os.Rename(tmp.Name(), d.path)
+}
+
+// counts bytes written and reports them, at most every 100ms
+type progressWriter struct {
+	done   int64
+	total  int64
+	last   time.Time
+	report func(done, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.done += int64(len(p))
+	if time.Since(w.last) > 100*time.Millisecond || w.done == w.total {
+		w.last = time.Now()
+		w.report(w.done, w.total)
+	}
+	return len(p), nil
+}
+
+// for pipes and logs, one line per finished download
+func runPlain(ctx context.Context, client *models.Client, downloads []*download, concurrency int) error {
+	var mu sync.Mutex
+	runDownloads(ctx, client, downloads, concurrency, func(i int, d download) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch d.state {
+		case finished:
+			fmt.Fprintf(os.Stderr, "downloaded %s to %s\n", d.identifier, d.path)
+		case skipped:
+			fmt.Fprintf(os.Stderr, "skipped %s, %s is up to date\n", d.identifier, d.path)
+		case failed:
+			fmt.Fprintf(os.Stderr, "failed %s: %v\n", d.identifier, d.err)
+		}
+	})
+	return nil
+}
+
+// shows a progress bar per download while they run. updates go through a
+// channel the model reads from rather than Program.Send, which blocks
+// forever once the program has quit.
+func runWithProgress(ctx context.Context, cancel context.CancelFunc, client *models.Client, downloads []*download, concurrency int) error {
+	// ctrl+c in the view cancels ctx, work is also stopped when the program
+	// ends for any other reason
+	work, stop := context.WithCancel(ctx)
+	defer stop()
+
+	updates := make(chan tea.Msg)
+	send := func(msg tea.Msg) {
+		select {
+		case updates <- msg:
+		case <-work.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runDownloads(work, client, downloads, concurrency, func(i int, d download) {
+			send(downloadMsg{index: i, download: d})
+		})
+		send(allDoneMsg{})
+	}()
+
+	err := tea.NewProgram(newProgressModel(downloads, updates, cancel)).Start()
+
+	// the workers have to be stopped and finished before their downloads
+	// are looked at
+	stop()
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tea message types sent by the download workers
+type downloadMsg struct {
+	index    int
+	download download
+}
+type allDoneMsg struct{}
+
+// lipgloss styles
+var (
+	nameStyle   = lipgloss.NewStyle().Width(24)
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	doneStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+)
+
+type progressModel struct {
+	downloads []download
+	bar       progress.Model
+	updates   <-chan tea.Msg
+	cancel    context.CancelFunc
+	quitting  bool
+}
+
+func newProgressModel(downloads []*download, updates <-chan tea.Msg, cancel context.CancelFunc) *progressModel {
+	m := progressModel{
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithWidth(40)),
+		updates: updates,
+		cancel:  cancel,
+	}
+	for _, d := range downloads {
+		m.downloads = append(m.downloads, *d)
+	}
+	return &m
+}
+
+// waits for the next message from the download workers
+func waitForUpdate(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+func (m *progressModel) Init() tea.Cmd {
+	return waitForUpdate(m.updates)
+}
+
+func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case downloadMsg:
+		m.downloads[msg.index] = msg.download
+		return m, waitForUpdate(m.updates)
+
+	case allDoneMsg:
+		m.quitting = true
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.quitting = true
+			m.cancel()
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// one line per download, finished ones show where they were saved
+func (m *progressModel) View() string {
+	var b strings.Builder
+
+	for _, d := range m.downloads {
+		b.WriteString(nameStyle.Render(truncate(d.identifier, 22)))
+
+		switch d.state {
+		case waiting:
+			b.WriteString(statusStyle.Render("waiting"))
+		case downloading:
+			b.WriteString(m.bar.ViewAs(fraction(d.done, d.total)))
+			b.WriteString(statusStyle.Render(" " + size(d.done)))
+		case skipped:
+			b.WriteString(statusStyle.Render("up to date " + d.path))
+		case finished:
+			b.WriteString(doneStyle.Render("saved " + d.path))
+		case failed:
+			b.WriteString(errorStyle.Render(fmt.Sprint(d.err)))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// progress of a download, unknown sizes show as empty until done
+func fraction(done, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(done) / float64(total)
+}
+
+func size(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
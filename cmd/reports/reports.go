@@ -0,0 +1,19 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package reports
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var ReportsCmd = &cobra.Command{
+	Use:     "reports",
+	Aliases: []string{"report"},
+	Short:   "Work with requisition reports",
+}
+
+func init() {
+	ReportsCmd.AddCommand(downloadCmd)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <identifier> <file>...",
+	Short: "Upload files like scanned forms or consents to a requisition",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		identifier, files := args[0], args[1:]
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+		// large scans can take longer than the default request timeout
+		client.Http.Timeout = 0
+
+		showProgress := cmdutil.IsTerminal(os.Stderr)
+		failed := 0
+
+		for _, path := range files {
+			upload, err := attachFile(context.Background(), client, identifier, path, showProgress)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "attached %s to %s\n", path, identifier)
+			fmt.Println(upload.AttachmentId)
+
+			// the file is on the requisition either way, so a missing history
+			// entry isn't worth failing, or retrying, the upload over
+			if err := data.RecordUpload(upload); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %s was uploaded but not added to the upload history: %v\n", path, err)
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d uploads failed", failed, len(files))
+		}
+		return nil
+	},
+}
+
+// uploads one file, the returned Upload is what goes in the local upload
+// history
+func attachFile(ctx context.Context, client *models.Client, identifier, path string, showProgress bool) (models.Upload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return models.Upload{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return models.Upload{}, err
+	}
+	if info.IsDir() {
+		return models.Upload{}, fmt.Errorf("is a directory")
+	}
+
+	contentType, err := utils.ContentType(f)
+	if err != nil {
+		return models.Upload{}, err
+	}
+
+	// every attempt sends the file from the start
+	open := func() (io.Reader, error) {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if showProgress {
+			return &progressReader{r: f, name: filepath.Base(path), total: info.Size()}, nil
+		}
+		return f, nil
+	}
+	if showProgress {
+		defer fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+
+	var res models.AttachmentResponse
+	if err := utils.UploadAttachment(ctx, client, identifier, filepath.Base(path), contentType, open, info.Size(), &res); err != nil {
+		return models.Upload{}, err
+	}
+
+	abs, _ := filepath.Abs(path)
+	return models.Upload{
+		Requisition:  identifier,
+		FilePath:     abs,
+		ContentType:  contentType,
+		Size:         info.Size(),
+		AttachmentId: res.Attachment.Id,
+		UploadedAt:   time.Now(),
+	}, nil
+}
+
+// prints how much of a file has been sent on a single updating line
+type progressReader struct {
+	r     io.Reader
+	name  string
+	done  int64
+	total int64
+	last  time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+
+	if time.Since(p.last) > 100*time.Millisecond || p.done == p.total {
+		p.last = time.Now()
+		percent := 100
+		if p.total > 0 {
+			percent = int(p.done * 100 / p.total)
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[Kuploading %s %d%%", p.name, percent)
+	}
+	return n, err
+}
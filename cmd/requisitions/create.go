@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create -f <file>",
+	Short: "Create requisitions from yaml or json files",
+	Long: `Create requisitions from yaml or json files.
+
+A file can hold several requisitions, either as yaml documents separated by
+"---" or as a list. Every requisition is validated before any is sent.
+requisition_template_id defaults to the stored project template id.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, _ := cmd.Flags().GetStringSlice("file")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		// dry runs only need the default template, not a token
+		params, err := cmdutil.StoredParams()
+		if err != nil {
+			return err
+		}
+
+		reqs, err := readRequisitionFiles(files, params.ProjTempId)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			for _, req := range reqs {
+				if err := cmdutil.PrintJSON(os.Stdout, utils.RequisitionPayload(req)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for i, req := range reqs {
+			var res models.RequisitionResponse
+			if err := utils.CreateRequisition(context.Background(), client, req, &res); err != nil {
+				fmt.Fprintf(os.Stderr, "requisition %d: %v\n", i+1, err)
+				failed++
+				continue
+			}
+			fmt.Println(res.Requisition.Identifier)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d requisitions failed", failed, len(reqs))
+		}
+		return nil
+	},
+}
+
+func init() {
+	createCmd.Flags().StringSliceP("file", "f", nil, "yaml or json file with one or more requisitions, - for stdin")
+	createCmd.Flags().Bool("dry-run", false, "print the payloads that would be sent without sending them")
+	createCmd.MarkFlagRequired("file")
+}
+
+// reads and validates every requisition in files. nothing is returned
+// unless all of them are valid.
+func readRequisitionFiles(files []string, defaultTemplate models.ProjectTemplateId) ([]models.RequisitionRequest, error) {
+	var reqs []models.RequisitionRequest
+	var invalid int
+
+	for _, name := range files {
+		docs, err := readDocuments(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, doc := range docs {
+			req, err := parseRequisition(doc, defaultTemplate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: document %d: %v\n", name, i+1, err)
+				invalid++
+				continue
+			}
+			reqs = append(reqs, req)
+		}
+	}
+
+	if invalid > 0 {
+		return nil, fmt.Errorf("%d invalid requisitions, nothing was sent", invalid)
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no requisitions found in %v", files)
+	}
+	return reqs, nil
+}
+
+func parseRequisition(doc json.RawMessage, defaultTemplate models.ProjectTemplateId) (models.RequisitionRequest, error) {
+	var req models.RequisitionRequest
+	if err := utils.DecodeStrict(doc, &req); err != nil {
+		return req, err
+	}
+	if req.RequisitionTemplateId == 0 {
+		req.RequisitionTemplateId = defaultTemplate
+	}
+	return req, req.Validate()
+}
+
+// reads every yaml/json document in a file, - reads stdin
+func readDocuments(name string) ([]json.RawMessage, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	docs, err := utils.DecodeDocuments(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return docs, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sabino-ramirez/oah/utils"
+)
+
+// lipgloss styles
+var (
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	pathStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("69"))
+)
+
+// one changed leaf value, paths are dotted like patient.first_name or
+// samples.0.status
+type fieldChange struct {
+	path string
+	old  string
+	new  string
+}
+
+// compares two decoded json documents leaf by leaf
+func diffFields(before, after map[string]any) []fieldChange {
+	old := map[string]string{}
+	flatten("", before, old)
+	updated := map[string]string{}
+	flatten("", after, updated)
+
+	paths := map[string]bool{}
+	for p := range old {
+		paths[p] = true
+	}
+	for p := range updated {
+		paths[p] = true
+	}
+
+	var changes []fieldChange
+	for p := range paths {
+		if old[p] != updated[p] {
+			changes = append(changes, fieldChange{path: p, old: old[p], new: updated[p]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes
+}
+
+// redacts both sides of changes to phi, the field is still shown so it's
+// clear what the update touches
+func maskChanges(changes []fieldChange) []fieldChange {
+	out := make([]fieldChange, len(changes))
+	for i, c := range changes {
+		if utils.IsPHIPath(c.path) {
+			c.old, c.new = maskedValue(c.old), maskedValue(c.new)
+		}
+		out[i] = c
+	}
+	return out
+}
+
+func maskedValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "REDACTED"
+}
+
+// renders changes one per line as path: old → new
+func renderDiff(changes []fieldChange) string {
+	width := 0
+	for _, c := range changes {
+		if len(c.path) > width {
+			width = len(c.path)
+		}
+	}
+
+	var b strings.Builder
+	for _, c := range changes {
+		old, new := c.old, c.new
+		if old == "" {
+			old = "(empty)"
+		}
+		if new == "" {
+			new = "(empty)"
+		}
+		fmt.Fprintf(&b, "  %s  %s → %s\n", pathStyle.Render(fmt.Sprintf("%-*s", width, c.path)), removedStyle.Render(old), addedStyle.Render(new))
+	}
+	return b.String()
+}
+
+func flatten(prefix string, v any, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			flatten(join(prefix, k), val, out)
+		}
+	case []any:
+		for i, val := range v {
+			flatten(join(prefix, strconv.Itoa(i)), val, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	default:
+		b, _ := json.Marshal(v)
+		out[prefix] = string(b)
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// sets a dotted path in m, creating nested maps along the way
+func setPath(m map[string]any, path string, value any) error {
+	parts := strings.Split(path, ".")
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := m[part]
+		if !ok || next == nil {
+			child := map[string]any{}
+			m[part] = child
+			m = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("can't set %s, %s is not an object", path, strings.Join(parts[:i+1], "."))
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+	return nil
+}
+
+// merges patch into doc the way a json merge patch would, objects are
+// merged key by key and everything else is replaced
+func mergePatch(doc, patch map[string]any) map[string]any {
+	out := make(map[string]any, len(doc))
+	for k, v := range doc {
+		out[k] = v
+	}
+
+	for k, v := range patch {
+		patchChild, isObject := v.(map[string]any)
+		docChild, docIsObject := out[k].(map[string]any)
+		if isObject && docIsObject {
+			out[k] = mergePatch(docChild, patchChild)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <identifier>",
+	Short: "Show the full detail of one requisition",
+	Long: `Show the full detail of one requisition.
+
+The patient's name, date of birth and other phi are masked unless
+--show-phi is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showPHI, _ := cmd.Flags().GetBool("show-phi")
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.RequisitionResponse
+		if err := utils.GetRequisition(context.Background(), client, args[0], &res); err != nil {
+			if utils.IsNotFound(err) {
+				return fmt.Errorf("requisition %s not found", args[0])
+			}
+			return err
+		}
+
+		requisition := res.Requisition
+		if !showPHI {
+			requisition = utils.MaskRequisition(requisition)
+		}
+
+		return cmdutil.Print(os.Stdout, requisition, func(w io.Writer) error {
+			return printRequisition(w, requisition)
+		})
+	},
+}
+
+func init() {
+	getCmd.Flags().Bool("show-phi", false, "show the patient's name, date of birth and mrn unmasked")
+}
+
+// writes a requisition as aligned key/value sections
+func printRequisition(out io.Writer, r models.Requisition) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Requisition")
+	fmt.Fprintf(w, "Identifier\t%s\n", r.Identifier)
+	fmt.Fprintf(w, "Template Id\t%d\n", r.RequisitionTemplateId)
+	fmt.Fprintf(w, "Status\t%s\n", r.Status)
+	fmt.Fprintf(w, "Accession\t%s\n", r.AccessionStatus)
+	fmt.Fprintf(w, "Processing\t%s\n", r.ProcessingStatus)
+	fmt.Fprintf(w, "Reporting\t%s\n", r.ReportingStatus)
+	fmt.Fprintf(w, "Billing\t%s\n", r.BillingStatus)
+	fmt.Fprintf(w, "Created\t%s\n", formatTime(r.CreatedAt.Time))
+	fmt.Fprintf(w, "Updated\t%s\n", formatTime(r.UpdatedAt.Time))
+
+	if r.Patient != nil {
+		section(w, "Patient")
+		fmt.Fprintf(w, "Identifier\t%s\n", r.Patient.Identifier)
+		fmt.Fprintf(w, "Name\t%s\n", strings.TrimSpace(r.Patient.FirstName+" "+r.Patient.LastName))
+		fmt.Fprintf(w, "Date of Birth\t%s\n", r.Patient.DateOfBirth)
+		fmt.Fprintf(w, "Sex\t%s\n", r.Patient.Sex)
+		fmt.Fprintf(w, "MRN\t%s\n", r.Patient.MRN)
+	}
+
+	if r.Provider != nil {
+		section(w, "Provider")
+		fmt.Fprintf(w, "Identifier\t%s\n", r.Provider.Identifier)
+		fmt.Fprintf(w, "Name\t%s\n", strings.TrimSpace(r.Provider.FirstName+" "+r.Provider.LastName))
+		fmt.Fprintf(w, "NPI\t%s\n", r.Provider.NPI)
+	}
+
+	section(w, "Samples")
+	if len(r.Samples) == 0 {
+		fmt.Fprintln(w, "none")
+	} else {
+		fmt.Fprintln(w, "Identifier\tBarcode\tType\tStatus\tLocation\tCollected\tReceived")
+		for _, s := range r.Samples {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Identifier, s.Barcode, s.SampleType, s.Status, s.Location(), formatTimePtr(s.CollectedAt), formatTimePtr(s.ReceivedAt))
+		}
+	}
+
+	if len(r.CustomAttributes) > 0 {
+		section(w, "Custom Attributes")
+		keys := make([]string, 0, len(r.CustomAttributes))
+		for k := range r.CustomAttributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s\t%v\n", k, r.CustomAttributes[k])
+		}
+	}
+
+	section(w, "Status History")
+	if len(r.StatusHistory) == 0 {
+		fmt.Fprintln(w, "none")
+	} else {
+		fmt.Fprintln(w, "When\tField\tFrom\tTo\tBy")
+		for _, c := range r.StatusHistory {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(c.ChangedAt), c.Field, c.From, c.To, c.ChangedBy)
+		}
+	}
+
+	return w.Flush()
+}
+
+// section headings are flushed on their own so they don't widen the columns
+func section(w *tabwriter.Writer, title string) {
+	w.Flush()
+	fmt.Fprintf(w, "\n%s\n", title)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
@@ -0,0 +1,382 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+// columnMapping is the --map file, it maps csv column headers onto
+// requisition fields and gives values for fields the csv doesn't have
+//
+//	columns:
+//	  First Name: patient.first_name
+//	  Sample Type: samples.0.sample_type
+//	defaults:
+//	  custom_attributes.source: intake
+type columnMapping struct {
+	Columns  map[string]string `json:"columns"`
+	Defaults map[string]any    `json:"defaults"`
+}
+
+// one csv data row, number is 1 for the first row after the header
+type importRow struct {
+	number int
+	req    models.RequisitionRequest
+}
+
+type importResult struct {
+	row        int
+	identifier string
+	err        error
+}
+
+var resultsHeader = []string{"row", "identifier", "error"}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file.csv> --map <mapping.yaml>",
+	Short: "Create requisitions from the rows of a csv file",
+	Long: `Create requisitions from the rows of a csv file.
+
+Every row is validated before anything is sent. The outcome of each row is
+written to a results csv, and --resume skips rows that already have an
+identifier in it. ctrl+c stops sending rows and waits for the ones in flight
+to be written.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mapFile, _ := cmd.Flags().GetString("map")
+		resultsFile, _ := cmd.Flags().GetString("results")
+		resume, _ := cmd.Flags().GetBool("resume")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if resultsFile == "" {
+			resultsFile = strings.TrimSuffix(args[0], ".csv") + ".results.csv"
+		}
+
+		mapping, err := readMapping(mapFile)
+		if err != nil {
+			return err
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		// ctrl+c cancels the requests in flight, their outcome is still
+		// written before exiting
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		done := map[int]importResult{}
+		if resume {
+			if done, err = readResults(resultsFile); err != nil {
+				return err
+			}
+		}
+
+		rows, err := readImportRows(args[0], mapping, client.ProjectTemplateId, done)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.Create(resultsFile)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		results := csv.NewWriter(out)
+		results.Write(resultsHeader)
+		for _, row := range sortedRows(done) {
+			writeResult(results, done[row])
+		}
+		results.Flush()
+		if err := results.Error(); err != nil {
+			return fmt.Errorf("writing %s: %w, nothing was sent", resultsFile, err)
+		}
+
+		created, failed, err := submitRows(ctx, client, rows, concurrency, results)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w, created %d before stopping, their identifiers are above", resultsFile, err, created)
+		}
+
+		fmt.Fprintf(os.Stderr, "created %d, failed %d, skipped %d, results in %s\n", created, failed, len(done), resultsFile)
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted, run again with --resume to send the rest")
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d rows failed, fix them and run again with --resume", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().String("map", "", "yaml or json file mapping csv columns to requisition fields")
+	importCmd.Flags().String("results", "", "where to write per row results (default <file>.results.csv)")
+	importCmd.Flags().Bool("resume", false, "skip rows the results file already has an identifier for")
+	importCmd.Flags().IntP("concurrency", "c", 4, "number of requisitions created at the same time")
+	importCmd.MarkFlagRequired("map")
+}
+
+func readMapping(name string) (columnMapping, error) {
+	var mapping columnMapping
+
+	docs, err := readDocuments(name)
+	if err != nil {
+		return mapping, err
+	}
+	if len(docs) != 1 {
+		return mapping, fmt.Errorf("%s: expected one mapping document, found %d", name, len(docs))
+	}
+	if err := utils.DecodeStrict(docs[0], &mapping); err != nil {
+		return mapping, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(mapping.Columns) == 0 {
+		return mapping, fmt.Errorf("%s: no columns mapped", name)
+	}
+	return mapping, nil
+}
+
+// turns every csv row into a validated request. rows in skip are left out.
+// nothing is returned unless every remaining row is valid.
+func readImportRows(name string, mapping columnMapping, defaultTemplate models.ProjectTemplateId, skip map[int]importResult) ([]importRow, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading header: %w", name, err)
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.TrimSpace(h)] = i
+	}
+	for col := range mapping.Columns {
+		if _, ok := columns[col]; !ok {
+			return nil, fmt.Errorf("%s: mapped column %q isn't in the header", name, col)
+		}
+	}
+
+	var rows []importRow
+	invalid := 0
+
+	for number := 1; ; number++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if _, ok := skip[number]; ok {
+			continue
+		}
+
+		req, err := rowRequest(record, columns, mapping, defaultTemplate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "row %d: %v\n", number, err)
+			invalid++
+			continue
+		}
+		rows = append(rows, importRow{number: number, req: req})
+	}
+
+	if invalid > 0 {
+		return nil, fmt.Errorf("%d invalid rows, nothing was sent", invalid)
+	}
+	return rows, nil
+}
+
+func rowRequest(record []string, columns map[string]int, mapping columnMapping, defaultTemplate models.ProjectTemplateId) (models.RequisitionRequest, error) {
+	doc := map[string]any{}
+
+	for path, value := range mapping.Defaults {
+		if err := setPath(doc, path, value); err != nil {
+			return models.RequisitionRequest{}, err
+		}
+	}
+
+	for col, path := range mapping.Columns {
+		value := strings.TrimSpace(record[columns[col]])
+		if value == "" {
+			continue
+		}
+		if err := setPath(doc, path, value); err != nil {
+			return models.RequisitionRequest{}, err
+		}
+	}
+
+	b, err := json.Marshal(listsFromIndexes(doc))
+	if err != nil {
+		return models.RequisitionRequest{}, err
+	}
+	return parseRequisition(b, defaultTemplate)
+}
+
+// setPath builds samples.0.sample_type as {"samples": {"0": {...}}}, this
+// turns objects whose keys are all indexes into lists
+func listsFromIndexes(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+
+	indexes := make([]int, 0, len(m))
+	for k, child := range m {
+		m[k] = listsFromIndexes(child)
+		if i, err := strconv.Atoi(k); err == nil && i >= 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(m) == 0 || len(indexes) != len(m) {
+		return m
+	}
+
+	sort.Ints(indexes)
+	list := make([]any, 0, len(indexes))
+	for _, i := range indexes {
+		list = append(list, m[strconv.Itoa(i)])
+	}
+	return list
+}
+
+// creates the rows with up to concurrency requests in flight, writing each
+// outcome to results as soon as it's known. no new rows are sent once ctx
+// is done or results can't be written, the ones in flight are still
+// waited for. rows created after a failed write are printed instead so
+// --resume doesn't create them again.
+func submitRows(ctx context.Context, client *models.Client, rows []importRow, concurrency int, results *csv.Writer) (created, failed int, err error) {
+	// stops feeding rows without canceling the ones in flight
+	feeding, stop := context.WithCancel(ctx)
+	defer stop()
+
+	jobs := make(chan importRow)
+	outcomes := make(chan importResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				var res models.RequisitionResponse
+				err := utils.CreateRequisition(ctx, client, row.req, &res)
+				outcomes <- importResult{row: row.number, identifier: res.Requisition.Identifier, err: err}
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for _, row := range rows {
+			select {
+			case jobs <- row:
+			case <-feeding.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	for outcome := range outcomes {
+		if outcome.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "row %d: %v\n", outcome.row, outcome.err)
+		} else {
+			created++
+		}
+
+		if err != nil {
+			if outcome.err == nil {
+				fmt.Fprintf(os.Stderr, "row %d: created %s\n", outcome.row, outcome.identifier)
+			}
+			continue
+		}
+		if err = writeResult(results, outcome); err != nil {
+			stop()
+			if outcome.err == nil {
+				fmt.Fprintf(os.Stderr, "row %d: created %s\n", outcome.row, outcome.identifier)
+			}
+		}
+	}
+	return created, failed, err
+}
+
+func writeResult(w *csv.Writer, r importResult) error {
+	errText := ""
+	if r.err != nil {
+		errText = r.err.Error()
+	}
+	w.Write([]string{strconv.Itoa(r.row), r.identifier, errText})
+	w.Flush()
+	return w.Error()
+}
+
+// reads the rows a previous run created, a missing file means nothing was
+func readResults(name string) (map[int]importResult, error) {
+	done := map[int]importResult{}
+
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for i, record := range records {
+		if i == 0 || len(record) < 2 || record[1] == "" {
+			continue
+		}
+		row, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: bad row number %q", name, i+1, record[0])
+		}
+		done[row] = importResult{row: row, identifier: record[1]}
+	}
+	return done, nil
+}
+
+func sortedRows(results map[int]importResult) []int {
+	rows := make([]int, 0, len(results))
+	for row := range results {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	return rows
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the project template's requisitions in a date range",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		until, _ := cmd.Flags().GetString("until")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		dates, err := utils.ParseDateRange(since, until, time.Now())
+		if err != nil {
+			return err
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		query := utils.RequisitionQuery{Range: dates, PageOptions: utils.PageOptions{MaxItems: limit}}
+		reqs, err := utils.ListProjectRequisitions(context.Background(), client, query)
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(reqs, func(i, j int) bool { return reqs[i].Identifier < reqs[j].Identifier })
+
+		return cmdutil.Print(os.Stdout, reqs, func(out io.Writer) error {
+			if len(reqs) == 0 {
+				fmt.Fprintf(os.Stderr, "no requisitions from %s\n", dates)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "Identifier\tStatus\tAccession\tProcessing\tReporting\tBilling\tCreated")
+			for _, r := range reqs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Identifier, r.Status, r.AccessionStatus, r.ProcessingStatus, r.ReportingStatus, r.BillingStatus, formatTime(r.CreatedAt.Time))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+func init() {
+	listCmd.Flags().String("since", "", "start of requisition date range (YYYY-MM-DD, MM-DD-YYYY, 7d, yesterday, this-month..)")
+	listCmd.Flags().String("until", "", "end of requisition date range, defaults to today")
+	listCmd.Flags().Int("limit", 0, "stop after this many requisitions, 0 lists them all")
+}
@@ -0,0 +1,29 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var RequisitionsCmd = &cobra.Command{
+	Use:     "requisitions",
+	Aliases: []string{"requisition", "reqs"},
+	Short:   "Work with requisitions",
+}
+
+func init() {
+	RequisitionsCmd.AddCommand(listCmd)
+	RequisitionsCmd.AddCommand(getCmd)
+	RequisitionsCmd.AddCommand(createCmd)
+	RequisitionsCmd.AddCommand(updateCmd)
+	RequisitionsCmd.AddCommand(importCmd)
+	RequisitionsCmd.AddCommand(attachCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+	cmdutil.AddFieldFlags(getCmd)
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package requisitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+// fields the api owns, they can't be patched
+var readOnlyFields = map[string]bool{
+	"identifier":     true,
+	"createdAt":      true,
+	"updatedAt":      true,
+	"status_history": true,
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update <identifier>",
+	Short: "Change fields of a requisition after previewing the diff",
+	Long: `Change fields of a requisition after previewing the diff.
+
+Fields are set with --set, using dots for nested fields
+(--set status=on_hold --set patient.first_name=Jane), or from a yaml/json
+patch file with -f. Patient fields are masked in the preview unless
+--show-phi is given. The update is sent on the condition that the
+requisition hasn't changed since the preview, otherwise nothing is changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sets, _ := cmd.Flags().GetStringArray("set")
+		patchFile, _ := cmd.Flags().GetString("file")
+		yes, _ := cmd.Flags().GetBool("yes")
+		showPHI, _ := cmd.Flags().GetBool("show-phi")
+		identifier := args[0]
+
+		patch, err := buildPatch(sets, patchFile)
+		if err != nil {
+			return err
+		}
+
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		var current models.RequisitionResponse
+		if err := utils.GetRequisition(ctx, client, identifier, &current); err != nil {
+			return err
+		}
+
+		before, err := toMap(current.Requisition)
+		if err != nil {
+			return err
+		}
+		if err := checkPatchFields(patch, before); err != nil {
+			return err
+		}
+		// without it there's no way to tell if someone else changed it
+		if current.Requisition.UpdatedAt.IsZero() {
+			return fmt.Errorf("%s has no updatedAt, a concurrent change couldn't be detected so nothing was sent", identifier)
+		}
+
+		changes := diffFields(before, mergePatch(before, patch))
+		if len(changes) == 0 {
+			fmt.Fprintln(os.Stderr, "nothing to change")
+			return nil
+		}
+
+		if !showPHI {
+			changes = maskChanges(changes)
+		}
+		fmt.Fprintf(os.Stderr, "%s\n%s", identifier, renderDiff(changes))
+		if !yes {
+			ok, err := cmdutil.Confirm(fmt.Sprintf("apply %d changes?", len(changes)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("update cancelled")
+			}
+		}
+
+		// someone may have changed it while we were waiting on the prompt
+		var latest models.RequisitionResponse
+		if err := utils.GetRequisition(ctx, client, identifier, &latest); err != nil {
+			return err
+		}
+		if !latest.Requisition.UpdatedAt.Equal(current.Requisition.UpdatedAt.Time) {
+			return fmt.Errorf("%s was modified at %s after it was fetched, nothing was sent, run the update again", identifier, formatTime(latest.Requisition.UpdatedAt.Time))
+		}
+
+		// the precondition also guards the moment between that check and
+		// the patch, if the api supports it
+		var updated models.RequisitionResponse
+		err = utils.UpdateRequisitionIfUnmodified(ctx, client, identifier, patch, current.Requisition.UpdatedAt.Time, &updated)
+		if utils.IsStale(err) {
+			return fmt.Errorf("%s was modified by someone else after its update at %s, nothing was changed, run the update again", identifier, formatTime(current.Requisition.UpdatedAt.Time))
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "updated %s\n", identifier)
+		return nil
+	},
+}
+
+func init() {
+	updateCmd.Flags().StringArray("set", nil, "field assignment like status=on_hold, can be repeated")
+	updateCmd.Flags().StringP("file", "f", "", "yaml or json patch file, - for stdin")
+	updateCmd.Flags().BoolP("yes", "y", false, "don't ask for confirmation")
+	updateCmd.Flags().Bool("show-phi", false, "show patient fields unmasked in the preview")
+}
+
+// combines the patch file and --set assignments, --set wins on conflicts
+func buildPatch(sets []string, patchFile string) (map[string]any, error) {
+	patch := map[string]any{}
+
+	if patchFile != "" {
+		docs, err := readDocuments(patchFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) != 1 {
+			return nil, fmt.Errorf("%s: expected one patch document, found %d", patchFile, len(docs))
+		}
+		if err := json.Unmarshal(docs[0], &patch); err != nil {
+			return nil, fmt.Errorf("%s: patch must be an object of fields: %w", patchFile, err)
+		}
+	}
+
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --set %q, expected field=value", set)
+		}
+		if err := setPath(patch, strings.TrimSpace(key), utils.ParseScalar(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(patch) == 0 {
+		return nil, fmt.Errorf("nothing to update, use --set or --file")
+	}
+	return patch, nil
+}
+
+// rejects top level fields the requisition doesn't have or that can't be changed
+func checkPatchFields(patch, current map[string]any) error {
+	var bad []string
+	for k := range patch {
+		if _, ok := current[k]; !ok || readOnlyFields[k] {
+			bad = append(bad, k)
+		}
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+
+	sort.Strings(bad)
+	return fmt.Errorf("can't update %s", strings.Join(bad, ", "))
+}
+
+// round trips v through json to get the generic form used for diffs
+func toMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	return m, json.Unmarshal(b, &m)
+}
@@ -23,16 +23,35 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
+	"github.com/sabino-ramirez/oah/cmd/billing"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/cmd/config"
+	"github.com/sabino-ramirez/oah/cmd/patients"
+	"github.com/sabino-ramirez/oah/cmd/profile"
+	"github.com/sabino-ramirez/oah/cmd/providers"
+	"github.com/sabino-ramirez/oah/cmd/reports"
+	"github.com/sabino-ramirez/oah/cmd/requisitions"
+	"github.com/sabino-ramirez/oah/cmd/samples"
 	"github.com/sabino-ramirez/oah/cmd/setup"
+	"github.com/sabino-ramirez/oah/cmd/templates"
 	"github.com/sabino-ramirez/oah/cmd/test"
+	"github.com/sabino-ramirez/oah/models"
 	"github.com/spf13/cobra"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "oah",
-	Short: "A brief description of your application",
+	Use:          "oah",
+	Short:        "A brief description of your application",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := cmdutil.SelectProfile(); err != nil {
+			return err
+		}
+		return cmdutil.CheckOutput()
+	},
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
@@ -47,8 +66,30 @@ func Execute() {
 
 func init() {
 	// rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-  rootCmd.AddCommand(setup.SetupCmd)
-  rootCmd.AddCommand(test.TestCmd)
-}
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.Profile, "profile", "", "use this profile's params instead of the default one, see 'oah profile' (env "+cmdutil.ProfileEnv+")")
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.BaseURL, "base-url", "", "api host to talk to, overrides the stored base url (default "+models.DefaultBaseURL+")")
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.APIVersion, "api-version", "", "api version to use, overrides the stored version (default "+models.DefaultAPIVersion+")")
+
+	rootCmd.PersistentFlags().BoolVarP(&cmdutil.Global.Verbose, "verbose", "v", false, "log method, url, status, latency and size of every request")
+	rootCmd.PersistentFlags().BoolVar(&cmdutil.Global.Trace, "trace", false, "like --verbose but also log headers and bodies, the token is always masked")
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.LogFile, "log-file", "", "append log output to this file instead of stderr (tui commands default to oah.log)")
+	rootCmd.PersistentFlags().IntVar(&cmdutil.Global.MaxAttempts, "max-attempts", 0, "max tries per request for 429/502/503/504 and network errors, 1 disables retries (default 4)")
+	rootCmd.PersistentFlags().DurationVar(&cmdutil.Global.RetryDeadline, "retry-deadline", 0, "stop retrying a request after this long (default 30s)")
 
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.Record, "record", "", "save every request and response to this cassette file, with secrets and phi redacted")
+	rootCmd.PersistentFlags().StringVar(&cmdutil.Global.Replay, "replay", "", "answer requests from this cassette file instead of the network")
 
+	rootCmd.PersistentFlags().StringVarP(&cmdutil.Global.Output, "output", "o", "", "output format for read commands: "+strings.Join(cmdutil.OutputFormats, ", ")+" (default table on a terminal, json otherwise)")
+
+	rootCmd.AddCommand(setup.SetupCmd)
+	rootCmd.AddCommand(test.TestCmd)
+	rootCmd.AddCommand(requisitions.RequisitionsCmd)
+	rootCmd.AddCommand(reports.ReportsCmd)
+	rootCmd.AddCommand(samples.SamplesCmd)
+	rootCmd.AddCommand(patients.PatientsCmd)
+	rootCmd.AddCommand(providers.ProvidersCmd)
+	rootCmd.AddCommand(billing.BillingCmd)
+	rootCmd.AddCommand(templates.TemplatesCmd)
+	rootCmd.AddCommand(config.ConfigCmd)
+	rootCmd.AddCommand(profile.ProfileCmd)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package samples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <barcode>",
+	Short: "Show a sample's status and location by its barcode",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.SampleResponse
+		if err := utils.GetSampleByBarcode(context.Background(), client, args[0], &res); err != nil {
+			if utils.IsNotFound(err) {
+				return fmt.Errorf("no sample with barcode %s", args[0])
+			}
+			return err
+		}
+
+		return cmdutil.Print(os.Stdout, res.Sample, func(w io.Writer) error {
+			return printSample(w, res.Sample)
+		})
+	},
+}
+
+var containerCmd = &cobra.Command{
+	Use:   "container <identifier>",
+	Short: "Show a container and where it's stored",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.ContainerResponse
+		if err := utils.GetContainer(context.Background(), client, args[0], &res); err != nil {
+			if utils.IsNotFound(err) {
+				return fmt.Errorf("container %s not found", args[0])
+			}
+			return err
+		}
+
+		return cmdutil.Print(os.Stdout, res.Container, func(out io.Writer) error {
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			printContainer(w, res.Container)
+			return w.Flush()
+		})
+	},
+}
+
+// writes a sample and its container as aligned key/value lines
+func printSample(out io.Writer, s models.Sample) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Sample")
+	fmt.Fprintf(w, "Identifier\t%s\n", s.Identifier)
+	fmt.Fprintf(w, "Barcode\t%s\n", s.Barcode)
+	fmt.Fprintf(w, "Requisition\t%s\n", s.Requisition)
+	fmt.Fprintf(w, "Type\t%s\n", s.SampleType)
+	fmt.Fprintf(w, "Status\t%s\n", s.Status)
+	fmt.Fprintf(w, "Collected\t%s\n", formatTimePtr(s.CollectedAt))
+	fmt.Fprintf(w, "Received\t%s\n", formatTimePtr(s.ReceivedAt))
+
+	if s.Container != nil {
+		w.Flush()
+		fmt.Fprintln(w)
+		printContainer(w, *s.Container)
+	}
+
+	return w.Flush()
+}
+
+func printContainer(w io.Writer, c models.Container) {
+	fmt.Fprintln(w, "Container")
+	fmt.Fprintf(w, "Identifier\t%s\n", c.Identifier)
+	fmt.Fprintf(w, "Barcode\t%s\n", c.Barcode)
+	fmt.Fprintf(w, "Type\t%s\n", c.ContainerType)
+	fmt.Fprintf(w, "Location\t%s\n", c.Location())
+}
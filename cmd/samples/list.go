@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package samples
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list <requisition>",
+	Short: "List the samples on a requisition",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.Samples
+		if err := utils.GetRequisitionSamples(context.Background(), client, args[0], &res); err != nil {
+			if utils.IsNotFound(err) {
+				return fmt.Errorf("requisition %s not found", args[0])
+			}
+			return err
+		}
+
+		samples := res.Samples
+		sort.SliceStable(samples, func(i, j int) bool { return samples[i].Identifier < samples[j].Identifier })
+
+		return cmdutil.Print(os.Stdout, samples, func(w io.Writer) error {
+			return printSamples(w, samples)
+		})
+	},
+}
+
+// writes one sample per line
+func printSamples(out io.Writer, samples []models.Sample) error {
+	if len(samples) == 0 {
+		fmt.Fprintln(out, "no samples")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Identifier\tBarcode\tType\tStatus\tLocation\tCollected\tReceived")
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Identifier, s.Barcode, s.SampleType, s.Status, s.Location(), formatTimePtr(s.CollectedAt), formatTimePtr(s.ReceivedAt))
+	}
+	return w.Flush()
+}
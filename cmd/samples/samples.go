@@ -0,0 +1,36 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package samples
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var SamplesCmd = &cobra.Command{
+	Use:     "samples",
+	Aliases: []string{"sample"},
+	Short:   "Look up samples and the containers they're stored in",
+}
+
+func init() {
+	SamplesCmd.AddCommand(listCmd)
+	SamplesCmd.AddCommand(getCmd)
+	SamplesCmd.AddCommand(containerCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+	cmdutil.AddFieldFlags(getCmd)
+	cmdutil.AddFieldFlags(containerCmd)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -4,13 +4,19 @@ Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
 package setup
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
 	"github.com/sabino-ramirez/oah/data"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
 
 	"github.com/spf13/cobra"
 )
@@ -22,6 +28,7 @@ type sessionState uint
 const (
 	inputView sessionState = iota
 	promptView
+	pickView
 )
 
 // lipgloss styles
@@ -36,9 +43,16 @@ var (
 type mainModel struct {
 	state     sessionState
 	TextInput textinput.Model
+	profile   string
 
 	params    []string
 	currParam int
+	inputErr  error
+	values    map[string]any
+
+	picker  list.Model
+	loading bool
+	pickErr error
 
 	choice int
 
@@ -51,18 +65,37 @@ type mainModel struct {
 // handling errors
 // tea message type for handling errors throughout program
 type errMsg struct{ err error }
+
+// tea message types for the organization/template lookups
+type pickItemsMsg []list.Item
+type pickErrMsg struct{ err error }
+
+// an organization or project template in the picker list
+type pickItem struct {
+	id    int64
+	title string
+	desc  string
+}
+
+func (i pickItem) Title() string       { return i.title }
+func (i pickItem) Description() string { return i.desc }
+func (i pickItem) FilterValue() string { return i.title }
+
 // in order to get errMsg type to implement error interface
 func (e errMsg) Error() string { return e.err.Error() }
 
 // returns what the initial model state will be
-func initialModel() *mainModel {
+func initialModel(profile string) *mainModel {
 	ti := textinput.New()
 	ti.Placeholder = "copy/paste or type.."
 	ti.Focus()
 	ti.Width = 20
 
+	picker := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	picker.SetShowHelp(false)
+
 	params := []string{"auth", "orgId", "projTempId"}
-	m := mainModel{state: inputView, TextInput: ti, params: params, currParam: 0, err: nil}
+	m := mainModel{state: inputView, TextInput: ti, profile: profile, params: params, currParam: 0, values: map[string]any{}, picker: picker, err: nil}
 	return &m
 }
 
@@ -81,7 +114,19 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case errMsg:
 		m.err = msg
 
+	case pickItemsMsg:
+		m.loading = false
+		m.picker.SetItems(msg)
+
+	case pickErrMsg:
+		m.loading = false
+		m.pickErr = msg.err
+
 	case tea.KeyMsg:
+		if m.state == pickView {
+			return m, m.updatePicker(msg)
+		}
+
 		switch msg.String() {
 		case "j", "down":
 			if m.state == promptView {
@@ -104,13 +149,24 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case tea.KeyEnter:
 			if m.state == inputView {
-				cmds = append(cmds, addToDb(m.params[m.currParam], m.TextInput.Value()))
+				value, err := data.ParseParam(m.params[m.currParam], m.TextInput.Value())
+				if err != nil {
+					m.inputErr = err
+					return m, nil
+				}
+				m.inputErr = nil
+				m.values[m.params[m.currParam]] = value
+				cmds = append(cmds, addToDb(m.params[m.currParam], value))
 				m.currParam++
 				m.TextInput.Reset()
 				m.state = promptView
 			} else {
 				if m.choice == 0 && m.currParam < 3 {
 					m.state = inputView
+				} else if m.currParam < 3 {
+					// doesn't know the id, look it up with the token instead
+					m.choice = 0
+					return m, m.startPicker()
 				} else {
 					if m.currParam > 2 {
 						return m, tea.Quit
@@ -134,6 +190,11 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.doResize(msg))
 	}
 
+	if m.state == pickView {
+		m.picker, cmd = m.picker.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
 	// if m.currParam > 3 {
 	// 	return m, tea.Quit
 	// }
@@ -155,6 +216,9 @@ func (m *mainModel) viewInput() string {
 	}
 
 	s = fmt.Sprintf("Enter %s\n\n%s\n\n", param, m.TextInput.View())
+	if m.inputErr != nil {
+		s += m.inputErr.Error()
+	}
 
 	return focusedModelStyle.Width(m.width / 2).Height(m.height / 4).Align(lipgloss.Center).Render(s)
 }
@@ -183,6 +247,24 @@ func (m *mainModel) viewPrompt() string {
 	return focusedModelStyle.Width(m.width / 3).Height(m.height / 2).Align(lipgloss.Center).Render(fmt.Sprintf(promptLabel, choices))
 }
 
+// organization/template picker view
+func (m *mainModel) viewPicker() string {
+	var s string
+
+	switch {
+	case m.loading:
+		s = fmt.Sprintf("Looking up %s..\n", m.picker.Title)
+	case m.pickErr != nil:
+		s = fmt.Sprintf("Couldn't look up %s:\n\n%v\n\nPress enter to type the id instead.\n", m.picker.Title, m.pickErr)
+	case len(m.picker.Items()) == 0:
+		s = fmt.Sprintf("No %s found for this token.\n\nPress enter to type the id instead.\n", m.picker.Title)
+	default:
+		s = m.picker.View()
+	}
+
+	return focusedModelStyle.Width(m.width / 2).Align(lipgloss.Left).Render(s)
+}
+
 // main view
 func (m *mainModel) View() string {
 	if m.err != nil {
@@ -191,20 +273,27 @@ func (m *mainModel) View() string {
 
 	inputBox := m.viewInput()
 	promptBox := m.viewPrompt()
+	header := helpStyle.Render("profile: " + m.profile + "\n")
 	footer := helpStyle.Render("\n↑/↓, j/k: navigate • ↵: enter/select • esc: exit\n")
 
 	if m.state == inputView {
-		complete := lipgloss.JoinVertical(lipgloss.Center, inputBox, footer)
+		complete := lipgloss.JoinVertical(lipgloss.Center, header, inputBox, footer)
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, complete)
 
 	}
 
-	complete := lipgloss.JoinVertical(lipgloss.Center, promptBox, footer)
+	if m.state == pickView {
+		footer = helpStyle.Render("\n↑/↓, j/k: navigate • /: filter • ↵: select • esc: exit\n")
+		complete := lipgloss.JoinVertical(lipgloss.Center, header, m.viewPicker(), footer)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, complete)
+	}
+
+	complete := lipgloss.JoinVertical(lipgloss.Center, header, promptBox, footer)
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, complete)
 }
 
 // tea command to add value to db
-func addToDb(key string, value string) tea.Cmd {
+func addToDb(key string, value any) tea.Cmd {
 	return func() tea.Msg {
 		if err := data.UpdateX(key, value); err != nil {
 			return errMsg{err}
@@ -213,9 +302,9 @@ func addToDb(key string, value string) tea.Cmd {
 	}
 }
 
-// tea command to create table and do default insert
+// tea command to create the profile being set up if it's new
 func checkDatabase() tea.Msg {
-	if err := data.CreateTable(); err != nil {
+	if err := data.InitParams(); err != nil {
 		return errMsg{err}
 	}
 	return nil
@@ -225,9 +314,119 @@ func checkDatabase() tea.Msg {
 func (m *mainModel) doResize(msg tea.WindowSizeMsg) tea.Cmd {
 	m.height = msg.Height
 	m.width = msg.Width
+	m.picker.SetSize(m.width/2, m.height/2)
 	return nil
 }
 
+// switches to the picker for the current param and kicks off the lookup
+func (m *mainModel) startPicker() tea.Cmd {
+	m.state = pickView
+	m.loading = true
+	m.pickErr = nil
+	m.picker.ResetFilter()
+	m.picker.ResetSelected()
+	m.picker.SetItems(nil)
+
+	client, err := m.client()
+	if err != nil {
+		m.loading = false
+		m.pickErr = err
+		return nil
+	}
+
+	if m.params[m.currParam] == "orgId" {
+		m.picker.Title = "organizations"
+		return fetchOrganizations(client)
+	}
+	m.picker.Title = "project templates"
+	return fetchProjectTemplates(client)
+}
+
+// handles keys while the picker is showing
+func (m *mainModel) updatePicker(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.picker.FilterState() == list.Unfiltered {
+			return tea.Quit
+		}
+	case tea.KeyEnter:
+		if m.loading {
+			return nil
+		}
+		if m.pickErr != nil || len(m.picker.Items()) == 0 {
+			m.state = inputView
+			return nil
+		}
+		if m.picker.FilterState() == list.Filtering {
+			break
+		}
+
+		item, ok := m.picker.SelectedItem().(pickItem)
+		if !ok {
+			return nil
+		}
+
+		key := m.params[m.currParam]
+		var value any = models.OrganizationId(item.id)
+		if key == "projTempId" {
+			value = models.ProjectTemplateId(item.id)
+		}
+		m.values[key] = value
+		m.currParam++
+		m.state = promptView
+		return addToDb(key, value)
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	return cmd
+}
+
+// api client using what has been entered so far, the environment and then
+// the stored values fill in the rest
+func (m *mainModel) client() (*models.Client, error) {
+	params, err := data.GetValues()
+	if err != nil {
+		return nil, err
+	}
+	if params, err = cmdutil.WithEnv(params); err != nil {
+		return nil, err
+	}
+	return cmdutil.NewClient(cmdutil.ApplyParams(params, m.values))
+}
+
+// tea command to list the organizations the token can access
+func fetchOrganizations(client *models.Client) tea.Cmd {
+	return func() tea.Msg {
+		var res models.Organizations
+		if err := utils.GetOrganizations(context.Background(), client, &res); err != nil {
+			return pickErrMsg{err}
+		}
+
+		items := make([]list.Item, 0, len(res.Organizations))
+		for _, org := range res.Organizations {
+			items = append(items, pickItem{id: int64(org.Id), title: org.Name, desc: "id " + org.Id.String()})
+		}
+		return pickItemsMsg(items)
+	}
+}
+
+// tea command to list the project templates of the chosen organization
+func fetchProjectTemplates(client *models.Client) tea.Cmd {
+	return func() tea.Msg {
+		var res models.ProjectTemplates
+		if err := utils.GetProjectTemplates(context.Background(), client, &res); err != nil {
+			return pickErrMsg{err}
+		}
+
+		items := make([]list.Item, 0, len(res.ProjectTemplates))
+		for _, t := range res.ProjectTemplates {
+			items = append(items, pickItem{id: int64(t.Id), title: t.TemplateName, desc: t.ProjectName + " • id " + t.Id.String()})
+		}
+		return pickItemsMsg(items)
+	}
+}
+
 // format checkboxes for prompt view
 func checkbox(label string, checked bool) string {
 	if checked {
@@ -240,11 +439,110 @@ func checkbox(label string, checked bool) string {
 var SetupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Enter Token and other parameters.",
-	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+	Long: `Enter Token and other parameters.
+
+Without flags setup runs as a full screen form. Given --token, --org or
+--template it saves those values without prompting and keeps the rest of
+the stored params.
+
+OAH_TOKEN, OAH_ORG_ID and OAH_PROJECT_TEMPLATE_ID override the stored
+params for every command without being saved, flags given to setup are
+saved and used over both.
+
+Values are saved to the active profile, pick another with --profile.`,
+	Example: "  oah setup --token $TOKEN --org 12 --template 101",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values, err := setupValues(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(values) > 0 {
+			return saveParams(values)
+		}
+
+		if !cmdutil.IsTerminal(os.Stdin) || !cmdutil.IsTerminal(os.Stdout) {
+			return fmt.Errorf("no terminal for the setup form, pass --token, --org and --template, or skip setup and set OAH_TOKEN, OAH_ORG_ID and OAH_PROJECT_TEMPLATE_ID")
+		}
+
+		profile, err := data.ActiveProfile()
+		if err != nil {
+			return err
+		}
+
+		cmdutil.LogToFileByDefault("oah.log")
+
+		p := tea.NewProgram(initialModel(profile), tea.WithAltScreen())
 
 		if err := p.Start(); err != nil {
 			log.Fatal(err)
 		}
+		return nil
 	},
 }
+
+// setup flags and the params column each one sets
+var setupFlags = []struct {
+	flag  string
+	param string
+}{
+	{"token", "auth"},
+	{"org", "orgId"},
+	{"template", "projTempId"},
+}
+
+func init() {
+	SetupCmd.Flags().String("token", "", "api token to save, OAH_TOKEN overrides it at run time")
+	SetupCmd.Flags().String("org", "", "organization id to save, OAH_ORG_ID overrides it at run time")
+	SetupCmd.Flags().String("template", "", "project template id to save, e.g. 101, OAH_PROJECT_TEMPLATE_ID overrides it at run time")
+}
+
+// collects the values given as flags. the environment is never saved, it
+// overrides the stored params at run time instead.
+func setupValues(cmd *cobra.Command) (map[string]any, error) {
+	values := map[string]any{}
+	for _, f := range setupFlags {
+		if !cmd.Flags().Changed(f.flag) {
+			continue
+		}
+		raw, _ := cmd.Flags().GetString(f.flag)
+		value, err := data.ParseParam(f.param, raw)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", f.flag, err)
+		}
+		values[f.param] = value
+	}
+	return values, nil
+}
+
+// stores values without touching the params that weren't given
+func saveParams(values map[string]any) error {
+	if err := data.InitParams(); err != nil {
+		return err
+	}
+
+	for _, f := range setupFlags {
+		value, ok := values[f.param]
+		if !ok {
+			continue
+		}
+		if err := data.UpdateX(f.param, value); err != nil {
+			return err
+		}
+
+		shown := fmt.Sprint(value)
+		if f.param == "auth" {
+			shown = cmdutil.MaskToken(shown)
+		}
+		fmt.Fprintf(os.Stderr, "saved %s %s (from --%s)\n", f.param, shown, f.flag)
+	}
+
+	// a saved value is only used once the variable is unset
+	for _, env := range cmdutil.EnvParams {
+		if _, ok := values[env.Param]; ok && os.Getenv(env.Name) != "" {
+			fmt.Fprintf(os.Stderr, "note: %s is set and overrides the saved %s\n", env.Name, env.Param)
+		}
+	}
+	return nil
+}
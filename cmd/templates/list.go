@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package templates
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/sabino-ramirez/oah/models"
+	"github.com/sabino-ramirez/oah/utils"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the organization's project templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := cmdutil.StoredClient()
+		if err != nil {
+			return err
+		}
+
+		var res models.ProjectTemplates
+		if err := utils.GetProjectTemplates(context.Background(), client, &res); err != nil {
+			return err
+		}
+
+		templates := res.ProjectTemplates
+		sort.SliceStable(templates, func(i, j int) bool { return templates[i].Id < templates[j].Id })
+
+		return cmdutil.Print(os.Stdout, templates, func(out io.Writer) error {
+			if len(templates) == 0 {
+				fmt.Fprintln(os.Stderr, "no project templates")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "Id\tProject\tTemplate")
+			for _, t := range templates {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", t.Id, t.ProjectName, t.TemplateName)
+			}
+			return w.Flush()
+		})
+	},
+}
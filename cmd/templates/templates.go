@@ -0,0 +1,23 @@
+/*
+Copyright © 2022 Sabino Ramirez <sabinoramirez017@gmail.com>
+*/
+package templates
+
+import (
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// cobra stuff
+var TemplatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"template"},
+	Short:   "Work with the organization's project templates",
+}
+
+func init() {
+	TemplatesCmd.AddCommand(listCmd)
+
+	// commands printing records take --fields and --template
+	cmdutil.AddFieldFlags(listCmd)
+}
@@ -5,17 +5,20 @@ package test
 
 // imports
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sabino-ramirez/oah/cmd/cmdutil"
 	"github.com/sabino-ramirez/oah/data"
 	"github.com/sabino-ramirez/oah/models"
 	"github.com/sabino-ramirez/oah/utils"
@@ -24,7 +27,19 @@ import (
 
 // tea message type for handling errors throughout program
 type errMsg struct{ err error }
-type statusMsg int
+
+// tea message type for the outcome of a test request
+type resultMsg struct {
+	statusCode int
+	err        error
+}
+
+// tea message type for the samples fetched in the samples view
+type samplesMsg struct {
+	requisition string
+	samples     []models.Sample
+	err         error
+}
 
 // app state variables will have this type
 type sessionState uint
@@ -33,15 +48,9 @@ type sessionState uint
 const (
 	dbItemsView sessionState = iota
 	resultsView
+	samplesView
 )
 
-var netTransport = &http.Transport{
-	Dial: (&net.Dialer{
-		Timeout: 5 * time.Second,
-	}).Dial,
-	TLSHandshakeTimeout: 5 * time.Second,
-}
-
 // lipgloss styles
 var (
 	modelStyle = lipgloss.NewStyle().Padding(0, 0, 0, 0).
@@ -67,20 +76,32 @@ var (
 type mainModel struct {
 	state          sessionState
 	prompt         bool
+	promptErr      error
 	chooseEndpoint bool
 	choice         int
 	dbItems        models.DbRow
 	statusCode     int
+	resultErr      error
+	dates          utils.DateRange
+	rangeStep      int
+	sinceInput     string
+	rangeErr       error
 	currParam      string
 	table          table.Model
 	textInput      textinput.Model
+	sampleInput    textinput.Model
+	sampleTable    table.Model
+	sampleReq      string
+	samples        []models.Sample
+	sampleErr      error
+	loading        bool
 	width          int
 	height         int
 	err            error
 }
 
 // function returns initial state
-func initialModel() *mainModel {
+func initialModel(dates utils.DateRange) *mainModel {
 	ti := textinput.New()
 	ti.Placeholder = "copy/paste or type.."
 	ti.Focus()
@@ -96,7 +117,23 @@ func initialModel() *mainModel {
 		table.WithFocused(true),
 	)
 
-	m := mainModel{state: resultsView, table: t, textInput: ti, chooseEndpoint: true}
+	si := textinput.New()
+	si.Placeholder = "requisition identifier"
+	si.Focus()
+	si.Width = 20
+
+	st := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Identifier", Width: 10},
+			{Title: "Barcode", Width: 9},
+			{Title: "Type", Width: 7},
+			{Title: "Status", Width: 9},
+			{Title: "Location", Width: 11},
+		}),
+		table.WithFocused(true),
+	)
+
+	m := mainModel{state: resultsView, table: t, textInput: ti, sampleInput: si, sampleTable: st, chooseEndpoint: true, dates: dates}
 	return &m
 }
 
@@ -110,8 +147,22 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
-	case statusMsg:
-		m.statusCode = int(msg)
+	case resultMsg:
+		m.statusCode = msg.statusCode
+		m.resultErr = msg.err
+
+	case samplesMsg:
+		m.loading = false
+		m.sampleReq = msg.requisition
+		m.sampleErr = msg.err
+		m.samples = msg.samples
+		rows := make([]table.Row, 0, len(msg.samples))
+		for _, s := range msg.samples {
+			rows = append(rows, table.Row{s.Identifier, s.Barcode, s.SampleType, s.Status, s.Location()})
+		}
+		m.sampleTable.SetRows(rows)
+		m.sampleTable.SetCursor(0)
+		return m, nil
 
 	case errMsg:
 		m.err = msg
@@ -132,28 +183,76 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.currParam = "orgId"
 					case "Proj. Temp. Id":
 						m.currParam = "projTempId"
+					case "Base URL":
+						m.currParam = "baseUrl"
+					case "API Version":
+						m.currParam = "apiVersion"
+					case "Rate Limit":
+						m.currParam = "rateLimit"
+					case "Burst":
+						m.currParam = "rateBurst"
 					}
 					m.prompt = true
 				} else {
+					value, err := data.ParseParam(m.currParam, m.textInput.Value())
+					if err != nil {
+						m.promptErr = err
+						return m, nil
+					}
 					m.prompt = false
-					return m, addToDb(m.currParam, m.textInput.Value())
+					m.promptErr = nil
+					m.textInput.Reset()
+					return m, addToDb(m.currParam, value)
 				}
 			case resultsView:
+				if m.rangeStep > 0 {
+					m.enterDate()
+					return m, nil
+				}
+				if m.chooseEndpoint && m.choice == 2 {
+					m.rangeStep = 1
+					m.rangeErr = nil
+					m.textInput.Reset()
+					return m, nil
+				}
 				if m.chooseEndpoint {
 					m.chooseEndpoint = false
+					m.statusCode, m.resultErr = 0, nil
 					return m, m.checkStatusCode(m.choice)
 				} else {
 					m.chooseEndpoint = true
 				}
+			case samplesView:
+				identifier := strings.TrimSpace(m.sampleInput.Value())
+				if identifier == "" || m.loading {
+					return m, nil
+				}
+				m.loading = true
+				m.sampleInput.Reset()
+				return m, m.fetchSamples(identifier)
 			}
 			// m.choice = 0
 
 		case tea.KeyTab:
-			if m.state == dbItemsView {
+			switch m.state {
+			case dbItemsView:
 				m.state = resultsView
-			} else {
+			case resultsView:
+				m.state = samplesView
+			default:
 				m.state = dbItemsView
 			}
+			return m, nil
+		}
+
+		if m.state == samplesView {
+			// arrows move through the samples, everything else is typing
+			if msg.Type == tea.KeyUp || msg.Type == tea.KeyDown {
+				m.sampleTable, cmd = m.sampleTable.Update(msg)
+			} else {
+				m.sampleInput, cmd = m.sampleInput.Update(msg)
+			}
+			return m, cmd
 		}
 
 		if m.state == dbItemsView {
@@ -164,13 +263,16 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table, cmd = m.table.Update(msg)
 				return m, cmd
 			}
+		} else if m.rangeStep > 0 {
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, cmd
 		} else {
 			switch msg.String() {
 			case "j", "down":
 				if m.state == resultsView {
 					m.choice += 1
-					if m.choice > 1 {
-						m.choice = 1
+					if m.choice > 2 {
+						m.choice = 2
 					}
 				}
 			case "k", "up":
@@ -194,8 +296,12 @@ func (m *mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *mainModel) viewDbItems() string {
 	m.table.SetRows([]table.Row{
 		{"Auth", m.dbItems.Auth},
-		{"Org Id", strconv.Itoa(m.dbItems.OrgId)},
-		{"Proj. Temp. Id", strconv.Itoa(m.dbItems.ProjTempId)},
+		{"Org Id", m.dbItems.OrgId.String()},
+		{"Proj. Temp. Id", m.dbItems.ProjTempId.String()},
+		{"Base URL", m.dbItems.BaseURL},
+		{"API Version", m.dbItems.APIVersion},
+		{"Rate Limit", strconv.FormatFloat(m.dbItems.RateLimit, 'f', -1, 64)},
+		{"Burst", strconv.Itoa(m.dbItems.RateBurst)},
 	})
 
 	s := table.DefaultStyles()
@@ -217,7 +323,11 @@ func (m *mainModel) viewDbItems() string {
 	m.table.SetWidth(m.width / 2)
 
 	if m.prompt {
-		return fmt.Sprintf("Enter %s\n\n%s\n\n", m.currParam, m.textInput.View())
+		s := fmt.Sprintf("Enter %s\n\n%s\n\n", m.currParam, m.textInput.View())
+		if m.promptErr != nil {
+			s += m.promptErr.Error()
+		}
+		return s
 	}
 
 	return baseStyle.Render(m.table.View()) + "\n\nMake a selection to edit value."
@@ -232,12 +342,22 @@ func (m *mainModel) viewResults() string {
 	c := m.choice
 
 	promptLabel = "Select Test Operation\n\n%s\n"
-	choices = lipgloss.JoinVertical(lipgloss.Left, checkbox("Get Project Templates", c == 0), checkbox("Get Requisitions", c == 1))
+	choices = lipgloss.JoinVertical(lipgloss.Left, checkbox("Get Project Templates", c == 0), checkbox("Get Requisitions", c == 1), checkbox("Set Date Range", c == 2))
+	choices += "\n\nrequisitions from " + m.dates.String()
+	if m.rangeErr != nil {
+		choices += "\n" + m.rangeErr.Error()
+	}
 
-	if m.chooseEndpoint {
+	if m.rangeStep == 1 {
+		s = fmt.Sprintf("Enter since date (e.g. 2022-01-31, 7d, this-month)\n\n%s\n\n", m.textInput.View())
+	} else if m.rangeStep == 2 {
+		s = fmt.Sprintf("Enter until date (e.g. today, yesterday)\n\n%s\n\n", m.textInput.View())
+	} else if m.chooseEndpoint {
 		s = fmt.Sprintf(promptLabel, choices)
+	} else if m.statusCode == 0 && m.resultErr == nil {
+		s = "loading.."
 	} else {
-		s = fmt.Sprintf("status code is: %v\n\n\n", m.statusCode)
+		s = fmt.Sprintf("%s\n\n%s\n\n", describeStatus(m.statusCode, m.resultErr), describeResult(m.resultErr))
 		s += checkbox("Ok", true)
 	}
 
@@ -245,21 +365,72 @@ func (m *mainModel) viewResults() string {
 	return s
 }
 
+// returns view for looking up the samples on a requisition
+func (m *mainModel) viewSamples() string {
+	s := fmt.Sprintf("Enter requisition\n\n%s\n\n", m.sampleInput.View())
+
+	switch {
+	case m.loading:
+		s += "loading.."
+	case m.sampleErr != nil:
+		s += describeResult(m.sampleErr)
+	case m.sampleReq == "":
+	case len(m.samples) == 0:
+		s += "no samples on " + m.sampleReq
+	default:
+		m.sampleTable.SetHeight(m.height / 6)
+		s += "samples on " + m.sampleReq + "\n\n" + baseStyle.Render(m.sampleTable.View())
+	}
+	return s
+}
+
 // main view
 func (m *mainModel) View() string {
-	var complete string
-	dbItemsBox := m.viewDbItems()
-	resultsBox := m.viewResults()
 	footer := helpStyle.Render("\n↑/↓, j/k: navigate • ↵: enter/select\ntab: switch view • esc: exit\n")
 
-	if m.state == dbItemsView {
-		complete = lipgloss.JoinVertical(lipgloss.Center, focusedModelStyle.Width(m.width/2).Height(m.height/4).Align(lipgloss.Center).Render("DB Items\n"+dbItemsBox), modelStyle.Width(m.width/2).Height(m.height/10).Align(lipgloss.Center).Render("Results"), footer)
-	} else {
-		complete = lipgloss.JoinVertical(lipgloss.Center, modelStyle.Width(m.width/2).Height(m.height/10).Align(lipgloss.Center).Render("DB Items"), focusedModelStyle.Width(m.width/2).Height(m.height/2).Align(lipgloss.Center).Render("Results\n\n"+resultsBox), footer)
+	dbTitle := "DB Items"
+	if m.dbItems.Profile != "" {
+		dbTitle += " (profile: " + m.dbItems.Profile + ")"
+	}
+
+	dbItems := modelStyle.Width(m.width / 2).Height(m.height / 10).Align(lipgloss.Center).Render(dbTitle)
+	results := modelStyle.Width(m.width / 2).Height(m.height / 10).Align(lipgloss.Center).Render("Results")
+	samples := modelStyle.Width(m.width / 2).Height(m.height / 10).Align(lipgloss.Center).Render("Samples")
+
+	switch m.state {
+	case dbItemsView:
+		dbItems = focusedModelStyle.Width(m.width / 2).Height(m.height / 4).Align(lipgloss.Center).Render(dbTitle + "\n" + m.viewDbItems())
+	case resultsView:
+		results = focusedModelStyle.Width(m.width / 2).Height(m.height / 2).Align(lipgloss.Center).Render("Results\n\n" + m.viewResults())
+	case samplesView:
+		samples = focusedModelStyle.Width(m.width / 2).Height(m.height / 2).Align(lipgloss.Center).Render("Samples\n\n" + m.viewSamples())
 	}
+
+	complete := lipgloss.JoinVertical(lipgloss.Center, dbItems, results, samples, footer)
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, complete)
 }
 
+// takes the date typed in the results view, the range is only applied
+// once both since and until have been entered and validated
+func (m *mainModel) enterDate() {
+	value := m.textInput.Value()
+	m.textInput.Reset()
+
+	if m.rangeStep == 1 {
+		m.sinceInput = value
+		m.rangeStep = 2
+		return
+	}
+
+	m.rangeStep = 0
+	dates, err := utils.ParseDateRange(m.sinceInput, value, time.Now())
+	if err != nil {
+		m.rangeErr = err
+		return
+	}
+	m.dates = dates
+}
+
 // cmd to re-render app when window is resized
 func (m *mainModel) doResize(msg tea.WindowSizeMsg) tea.Cmd {
 	m.height = msg.Height
@@ -271,9 +442,14 @@ func (m *mainModel) doResize(msg tea.WindowSizeMsg) tea.Cmd {
 func (m *mainModel) refreshDbItems() tea.Msg {
 	params, err := data.GetValues()
 
+	m.dbItems.Profile = params.Profile
 	m.dbItems.Auth = params.Auth
 	m.dbItems.OrgId = params.OrgId
 	m.dbItems.ProjTempId = params.ProjTempId
+	m.dbItems.BaseURL = params.BaseURL
+	m.dbItems.APIVersion = params.APIVersion
+	m.dbItems.RateLimit = params.RateLimit
+	m.dbItems.RateBurst = params.RateBurst
 
 	if err != nil {
 		return errMsg{err}
@@ -283,7 +459,7 @@ func (m *mainModel) refreshDbItems() tea.Msg {
 }
 
 // cmd update db value
-func addToDb(key string, value string) tea.Cmd {
+func addToDb(key string, value any) tea.Cmd {
 	return func() tea.Msg {
 		if err := data.UpdateX(key, value); err != nil {
 			return errMsg{err}
@@ -295,29 +471,93 @@ func addToDb(key string, value string) tea.Cmd {
 // cmd for getting status code based on endpoint position in results view list
 func (m *mainModel) checkStatusCode(choice int) tea.Cmd {
 	return func() tea.Msg {
-		client := &http.Client{Timeout: time.Second * 10, Transport: netTransport}
-		ovationAPI := models.NewClient(client, m.dbItems.OrgId, m.dbItems.ProjTempId, "Bearer "+m.dbItems.Auth)
+		params, err := cmdutil.WithEnv(m.dbItems)
+		if err != nil {
+			return resultMsg{err: err}
+		}
+		ovationAPI, err := cmdutil.NewClient(params)
+		if err != nil {
+			return resultMsg{err: err}
+		}
 
 		var projectReqs models.ProjectRequisitions
 		var projectIds models.ProjectTemplates
 
-		var statusCode int
-
 		switch choice {
 		case 0:
-			statusCode, _ = utils.GetProjectTemplates(ovationAPI, &projectIds)
-			// if err != nil {
-			// 	log.Println("error reading response:", err)
-			// }
-
+			err = utils.GetProjectTemplates(context.Background(), ovationAPI, &projectIds)
 		case 1:
-			statusCode, _ = utils.GetProjectRequisitions(ovationAPI, &projectReqs)
-			// if err != nil {
-			// log.Println("error reading response:", err)
-			// }
+			err = utils.GetProjectRequisitions(context.Background(), ovationAPI, m.dates, &projectReqs)
+		}
+
+		var apiErr *utils.APIError
+		var decodeErr *utils.DecodeError
+		switch {
+		case err == nil:
+			return resultMsg{statusCode: http.StatusOK}
+		case errors.As(err, &apiErr):
+			return resultMsg{statusCode: apiErr.StatusCode, err: err}
+		case errors.As(err, &decodeErr):
+			return resultMsg{statusCode: decodeErr.StatusCode, err: err}
+		default:
+			return resultMsg{err: err}
+		}
+	}
+}
+
+// cmd for fetching the samples on a requisition for the samples view
+func (m *mainModel) fetchSamples(identifier string) tea.Cmd {
+	params := m.dbItems
+	return func() tea.Msg {
+		params, err := cmdutil.WithEnv(params)
+		if err != nil {
+			return samplesMsg{requisition: identifier, err: err}
 		}
+		ovationAPI, err := cmdutil.NewClient(params)
+		if err != nil {
+			return samplesMsg{requisition: identifier, err: err}
+		}
+
+		var res models.Samples
+		err = utils.GetRequisitionSamples(context.Background(), ovationAPI, identifier, &res)
+		return samplesMsg{requisition: identifier, samples: res.Samples, err: err}
+	}
+}
+
+// first line of the results view, requests that never got a response
+// have no status code
+func describeStatus(code int, err error) string {
+	var decodeErr *utils.DecodeError
+	switch {
+	case errors.As(err, &decodeErr):
+		return fmt.Sprintf("status code is: %v, but the response isn't valid json", code)
+	case code == 0:
+		return "no response"
+	}
+	return fmt.Sprintf("status code is: %v", code)
+}
 
-		return statusMsg(statusCode)
+// short explanation of a failed test request for the results view
+func describeResult(err error) string {
+	var apiErr *utils.APIError
+	var decodeErr *utils.DecodeError
+	var transportErr *utils.TransportError
+
+	switch {
+	case err == nil:
+		return "request succeeded"
+	case utils.IsUnauthorized(err):
+		return "token was rejected, check auth"
+	case utils.IsNotFound(err):
+		return "not found, check org id / proj. temp. id"
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return "server is having trouble, try again later"
+	case errors.As(err, &decodeErr):
+		return "server sent a malformed response: " + decodeErr.Err.Error()
+	case errors.As(err, &transportErr):
+		return "could not reach server"
+	default:
+		return err.Error()
 	}
 }
 
@@ -337,10 +577,25 @@ var TestCmd = &cobra.Command{
 	Use:   "test",
 	Short: "Test the endpoints with parameters entered in setup",
 	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(initialModel(), tea.WithAltScreen())
+		since, _ := cmd.Flags().GetString("since")
+		until, _ := cmd.Flags().GetString("until")
+
+		dates, err := utils.ParseDateRange(since, until, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		cmdutil.LogToFileByDefault("oah.log")
+
+		p := tea.NewProgram(initialModel(dates), tea.WithAltScreen())
 
 		if err := p.Start(); err != nil {
 			log.Fatal(err)
 		}
 	},
 }
+
+func init() {
+	TestCmd.Flags().String("since", "", "start of requisition date range (YYYY-MM-DD, MM-DD-YYYY, 7d, yesterday, this-month..)")
+	TestCmd.Flags().String("until", "", "end of requisition date range, defaults to today")
+}
@@ -2,7 +2,9 @@ package data
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"path/filepath"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sabino-ramirez/oah/models"
@@ -10,60 +12,167 @@ import (
 
 var db *sql.DB
 var err error
+var dbPath string
 
 func InitDB(dburl string) error {
+	dbPath = dburl
 	db, err = sql.Open("sqlite3", dburl)
 	if err != nil {
 		return err
 	}
 	// log.Println("DB created")
-	return db.Ping()
+	if err = db.Ping(); err != nil {
+		return err
+	}
+	if err = createHistoryTables(); err != nil {
+		return err
+	}
+	if err = createProfileTables(); err != nil {
+		return err
+	}
+	if err = migrate(); err != nil {
+		return err
+	}
+	return migrateParams()
 }
 
-func CreateTable() error {
-	createTableSQL := `CREATE TABLE IF NOT EXISTS params(tryId INTEGER NOT NULL PRIMARY KEY CHECK (tryId = 1), auth TEXT, orgId INT, projTempId INT );`
+// columns added to params after the table was first released, older
+// databases get them added in place
+var addedColumns = map[string]string{
+	"baseUrl":    "TEXT",
+	"apiVersion": "TEXT",
+	"rateLimit":  "REAL",
+	"rateBurst":  "INT",
+}
 
-	statement, err := db.Prepare(createTableSQL)
+// brings an old params table up to date with the current columns so
+// migrateParams can copy every one of them
+func migrate() error {
+	rows, err := db.Query(`PRAGMA table_info(params);`)
 	if err != nil {
-		return fmt.Errorf("error preparing create table statement: %v", err)
+		return fmt.Errorf("error reading params columns: %v", err)
 	}
 
-	statement.Exec()
-	// log.Println("Created params table")
+	existing := map[string]bool{}
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("error reading params columns: %v", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
 
-	insertDefaultSQL := `REPLACE INTO params (tryId, auth, orgId, projTempId) VALUES (1, 1, 1, 1)`
-	statement, err = db.Prepare(insertDefaultSQL)
-	if err != nil {
-		return fmt.Errorf("error preparing insert statement: %v", err)
+	// no params table, there's nothing to bring up to date
+	if len(existing) == 0 {
+		return nil
+	}
+
+	for name, colType := range addedColumns {
+		if existing[name] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE params ADD COLUMN ` + name + ` ` + colType); err != nil {
+			return fmt.Errorf("error adding %v column: %v", name, err)
+		}
 	}
 
-	statement.Exec()
-	// log.Println("default insert successful")
+	return nil
+}
+
+// InitParams creates the active profile when it doesn't exist yet, an
+// existing profile keeps whatever is already stored
+func InitParams() error {
+	name, err := ActiveProfile()
+	if err != nil {
+		return err
+	}
 
+	if _, err := db.Exec(`INSERT OR IGNORE INTO profiles (name) VALUES (?)`, name); err != nil {
+		return fmt.Errorf("error creating profile %v: %v", name, err)
+	}
 	return nil
 }
 
+// UpdateX sets one param of the active profile
 func UpdateX(key string, value any) error {
-	updateSQL := `UPDATE params SET ` + key + ` = ? WHERE tryId = 1`
+	// stores the normalized value, e.g. trimmed, not what was passed in
+	value, err := ParseParam(key, fmt.Sprint(value))
+	if err != nil {
+		return err
+	}
+
+	name, err := ActiveProfile()
+	if err != nil {
+		return err
+	}
+
+	updateSQL := `UPDATE profiles SET ` + key + ` = ? WHERE name = ?`
 	statement, err := db.Prepare(updateSQL)
 	if err != nil {
 		return fmt.Errorf("error preparing update statement: %v", err)
 	}
+	defer statement.Close()
 
-	statement.Exec(value)
-	// log.Printf("%v update successful", key)
+	res, err := statement.Exec(value, name)
+	if err != nil {
+		return fmt.Errorf("error updating %v: %v", key, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errNoProfile(name)
+	}
 
 	return nil
 }
 
+// GetValues reads the params of the active profile
 func GetValues() (models.DbRow, error) {
-	selectSQL := `SELECT auth, orgId, projTempId FROM params WHERE tryId = 1;`
+	name, err := ActiveProfile()
+	if err != nil {
+		return models.DbRow{}, err
+	}
 
-	row := db.QueryRow(selectSQL)
+	selectSQL := `SELECT name, COALESCE(auth, ''), COALESCE(orgId, 0), COALESCE(projTempId, 0), COALESCE(baseUrl, ''), COALESCE(apiVersion, ''), COALESCE(rateLimit, 0), COALESCE(rateBurst, 0) FROM profiles WHERE name = ?;`
+
+	row := db.QueryRow(selectSQL, name)
 	params := models.DbRow{}
-	if err = row.Scan(&params.Auth, &params.OrgId, &params.ProjTempId); err != nil {
-		return models.DbRow{}, fmt.Errorf("not found: %v", err)
+	err = row.Scan(&params.Profile, &params.Auth, &params.OrgId, &params.ProjTempId, &params.BaseURL, &params.APIVersion, &params.RateLimit, &params.RateBurst)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DbRow{Profile: name}, errNoProfile(name)
+	}
+	if err != nil {
+		return models.DbRow{Profile: name}, fmt.Errorf("not found: %v", err)
 	}
 
 	return params, nil
 }
+
+// UnsetX clears a param of the active profile so its default is used again
+func UnsetX(key string) error {
+	if !knownParam(key) {
+		return fmt.Errorf("unknown parameter %q", key)
+	}
+
+	name, err := ActiveProfile()
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`UPDATE profiles SET `+key+` = NULL WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("error clearing %v: %v", key, err)
+	}
+	return nil
+}
+
+// Path is the location of the database file
+func Path() (string, error) {
+	return filepath.Abs(dbPath)
+}
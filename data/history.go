@@ -0,0 +1,103 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// creates the tables that record what oah has done, they aren't reset by
+// setup like params is
+func createHistoryTables() error {
+	createSQL := `CREATE TABLE IF NOT EXISTS uploads(id INTEGER PRIMARY KEY AUTOINCREMENT, requisition TEXT NOT NULL, filePath TEXT NOT NULL, contentType TEXT, size INT, attachmentId INT, uploadedAt DATETIME NOT NULL);`
+
+	if _, err := db.Exec(createSQL); err != nil {
+		return fmt.Errorf("error creating uploads table: %v", err)
+	}
+
+	createSQL = `CREATE TABLE IF NOT EXISTS billingAudit(id INTEGER PRIMARY KEY AUTOINCREMENT, requisition TEXT NOT NULL, fromStatus TEXT, toStatus TEXT NOT NULL, error TEXT, changedAt DATETIME NOT NULL, pending INTEGER NOT NULL DEFAULT 0);`
+
+	if _, err := db.Exec(createSQL); err != nil {
+		return fmt.Errorf("error creating billingAudit table: %v", err)
+	}
+
+	// audits created before changes were recorded up front
+	var hasPending int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('billingAudit') WHERE name = 'pending'`).Scan(&hasPending); err != nil {
+		return fmt.Errorf("error reading billingAudit columns: %v", err)
+	}
+	if hasPending == 0 {
+		if _, err := db.Exec(`ALTER TABLE billingAudit ADD COLUMN pending INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("error adding pending column: %v", err)
+		}
+	}
+	return nil
+}
+
+func RecordUpload(u models.Upload) error {
+	insertSQL := `INSERT INTO uploads (requisition, filePath, contentType, size, attachmentId, uploadedAt) VALUES (?, ?, ?, ?, ?, ?)`
+
+	if _, err := db.Exec(insertSQL, u.Requisition, u.FilePath, u.ContentType, u.Size, u.AttachmentId, u.UploadedAt); err != nil {
+		return fmt.Errorf("error recording upload: %v", err)
+	}
+	return nil
+}
+
+// RecordBillingChange adds a billing status change that wasn't sent, or
+// whose outcome is already known, to the audit table. failed attempts are
+// kept along with their error.
+func RecordBillingChange(c models.BillingChange) error {
+	insertSQL := `INSERT INTO billingAudit (requisition, fromStatus, toStatus, error, changedAt) VALUES (?, ?, ?, ?, ?)`
+
+	if _, err := db.Exec(insertSQL, c.Requisition, c.From, c.To, c.Error, c.ChangedAt); err != nil {
+		return fmt.Errorf("error recording billing change: %v", err)
+	}
+	return nil
+}
+
+// StartBillingChange records a change as pending before it's sent, so the
+// audit knows about it even if the outcome can't be recorded. the returned
+// id is passed to FinishBillingChange.
+func StartBillingChange(c models.BillingChange) (int64, error) {
+	insertSQL := `INSERT INTO billingAudit (requisition, fromStatus, toStatus, changedAt, pending) VALUES (?, ?, ?, ?, 1)`
+
+	res, err := db.Exec(insertSQL, c.Requisition, c.From, c.To, c.ChangedAt)
+	if err != nil {
+		return 0, fmt.Errorf("error recording billing change: %v", err)
+	}
+	return res.LastInsertId()
+}
+
+// FinishBillingChange stores the outcome of a pending change, errMsg is
+// empty when it went through
+func FinishBillingChange(id int64, errMsg string, changedAt time.Time) error {
+	updateSQL := `UPDATE billingAudit SET pending = 0, error = ?, changedAt = ? WHERE id = ?`
+
+	if _, err := db.Exec(updateSQL, errMsg, changedAt, id); err != nil {
+		return fmt.Errorf("error recording billing change result: %v", err)
+	}
+	return nil
+}
+
+// BillingChanges returns the most recent entries of the billing audit
+// table, newest first
+func BillingChanges(limit int) ([]models.BillingChange, error) {
+	querySQL := `SELECT requisition, COALESCE(fromStatus, ''), toStatus, COALESCE(error, ''), changedAt, pending FROM billingAudit ORDER BY id DESC LIMIT ?`
+
+	rows, err := db.Query(querySQL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error reading billing audit: %v", err)
+	}
+	defer rows.Close()
+
+	var changes []models.BillingChange
+	for rows.Next() {
+		var c models.BillingChange
+		if err := rows.Scan(&c.Requisition, &c.From, &c.To, &c.Error, &c.ChangedAt, &c.Pending); err != nil {
+			return nil, fmt.Errorf("error reading billing audit: %v", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
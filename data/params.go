@@ -0,0 +1,83 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// ParamKeys are the columns of the params table, in the order setup asks
+// for them
+var ParamKeys = []string{"auth", "orgId", "projTempId", "baseUrl", "apiVersion", "rateLimit", "rateBurst"}
+
+// anything shorter is almost certainly a partial paste
+const minTokenLen = 16
+
+func knownParam(key string) bool {
+	for _, k := range ParamKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseParam validates value for the params column key and converts it to
+// the type stored in the db
+func ParseParam(key string, value string) (any, error) {
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "auth":
+		return value, checkToken(value)
+	case "orgId":
+		return models.ParseOrganizationId(value)
+	case "projTempId":
+		return models.ParseProjectTemplateId(value)
+	case "baseUrl":
+		if err := models.ValidateBaseURL(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "apiVersion":
+		if !apiVersionPattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid api version %q, expected something like %s", value, models.DefaultAPIVersion)
+		}
+		return value, nil
+	case "rateLimit":
+		rps, err := strconv.ParseFloat(value, 64)
+		if err != nil || rps < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: must be requests per second, 0 for no limit", value)
+		}
+		return rps, nil
+	case "rateBurst":
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst < 0 {
+			return nil, fmt.Errorf("invalid burst %q: must be a whole number", value)
+		}
+		return burst, nil
+	}
+
+	return nil, fmt.Errorf("unknown parameter %q", key)
+}
+
+// catches values that can't be a token, like a pasted header or a
+// truncated copy
+func checkToken(token string) error {
+	switch {
+	case token == "":
+		return fmt.Errorf("auth token can't be empty")
+	case strings.HasPrefix(strings.ToLower(token), "bearer "):
+		return fmt.Errorf("auth token should be given without the \"Bearer \" prefix")
+	case strings.ContainsAny(token, " \t\r\n"):
+		return fmt.Errorf("auth token can't contain whitespace")
+	case len(token) < minTokenLen:
+		return fmt.Errorf("auth token is too short, expected at least %d characters", minTokenLen)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// DefaultProfile is used when no profile was picked with 'oah profile use'
+const DefaultProfile = "default"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// profile picked for this run with --profile, empty means the remembered
+// default
+var selectedProfile string
+
+func createProfileTables() error {
+	createSQL := `CREATE TABLE IF NOT EXISTS profiles(name TEXT NOT NULL PRIMARY KEY, auth TEXT, orgId INT, projTempId INT, baseUrl TEXT, apiVersion TEXT, rateLimit REAL, rateBurst INT);`
+
+	if _, err := db.Exec(createSQL); err != nil {
+		return fmt.Errorf("error creating profiles table: %v", err)
+	}
+
+	createSQL = `CREATE TABLE IF NOT EXISTS settings(key TEXT NOT NULL PRIMARY KEY, value TEXT);`
+
+	if _, err := db.Exec(createSQL); err != nil {
+		return fmt.Errorf("error creating settings table: %v", err)
+	}
+	return nil
+}
+
+// copies the values of the old single row params table into the default
+// profile, then renames params to params_backup so there's one place
+// settings live. the backup is kept in case the copy missed something, an
+// existing backup gets a numbered name next to it.
+func migrateParams() error {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'params'`).Scan(&count); err != nil {
+		return fmt.Errorf("error checking for params table: %v", err)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	copySQL := `INSERT OR IGNORE INTO profiles (name, auth, orgId, projTempId, baseUrl, apiVersion, rateLimit, rateBurst) SELECT ?, auth, orgId, projTempId, baseUrl, apiVersion, rateLimit, rateBurst FROM params WHERE tryId = 1`
+
+	if _, err := tx.Exec(copySQL, DefaultProfile); err != nil {
+		return fmt.Errorf("error copying params to the %v profile: %v", DefaultProfile, err)
+	}
+
+	backup := "params_backup"
+	for n := 2; ; n++ {
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, backup).Scan(&exists); err != nil {
+			return fmt.Errorf("error checking for %v table: %v", backup, err)
+		}
+		if exists == 0 {
+			break
+		}
+		backup = fmt.Sprintf("params_backup_%d", n)
+	}
+	if _, err := tx.Exec(`ALTER TABLE params RENAME TO ` + backup); err != nil {
+		return fmt.Errorf("error renaming params table to %v: %v", backup, err)
+	}
+	return tx.Commit()
+}
+
+func errNoProfile(name string) error {
+	return fmt.Errorf("profile %q doesn't exist, create it with 'oah profile create %s' or 'oah --profile %s setup'", name, name, name)
+}
+
+// CheckProfileName reports names that can't be used for a profile
+func CheckProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// SelectProfile makes name the active profile for the rest of this run
+func SelectProfile(name string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	selectedProfile = name
+	return nil
+}
+
+// ActiveProfile is the profile params are read from and written to, the
+// one picked with SelectProfile or else the remembered default
+func ActiveProfile() (string, error) {
+	if selectedProfile != "" {
+		return selectedProfile, nil
+	}
+	return RememberedProfile()
+}
+
+// RememberedProfile is the profile set with 'oah profile use', or
+// DefaultProfile when none was
+func RememberedProfile() (string, error) {
+	var name string
+	err := db.QueryRow(`SELECT value FROM settings WHERE key = 'defaultProfile'`).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) || name == "" {
+		return DefaultProfile, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading default profile: %v", err)
+	}
+	return name, nil
+}
+
+// UseProfile remembers name as the profile to use when --profile isn't given
+func UseProfile(name string) error {
+	if _, err := getProfile(name); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`REPLACE INTO settings (key, value) VALUES ('defaultProfile', ?)`, name); err != nil {
+		return fmt.Errorf("error saving default profile: %v", err)
+	}
+	return nil
+}
+
+// Profiles lists every profile sorted by name
+func Profiles() ([]models.DbRow, error) {
+	rows, err := db.Query(`SELECT name, COALESCE(auth, ''), COALESCE(orgId, 0), COALESCE(projTempId, 0), COALESCE(baseUrl, ''), COALESCE(apiVersion, ''), COALESCE(rateLimit, 0), COALESCE(rateBurst, 0) FROM profiles ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading profiles: %v", err)
+	}
+	defer rows.Close()
+
+	var profiles []models.DbRow
+	for rows.Next() {
+		var p models.DbRow
+		if err := rows.Scan(&p.Profile, &p.Auth, &p.OrgId, &p.ProjTempId, &p.BaseURL, &p.APIVersion, &p.RateLimit, &p.RateBurst); err != nil {
+			return nil, fmt.Errorf("error reading profiles: %v", err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+func getProfile(name string) (models.DbRow, error) {
+	profiles, err := Profiles()
+	if err != nil {
+		return models.DbRow{}, err
+	}
+	for _, p := range profiles {
+		if p.Profile == name {
+			return p, nil
+		}
+	}
+	return models.DbRow{}, errNoProfile(name)
+}
+
+// CreateProfile adds an empty profile, or a copy of from when it's given
+func CreateProfile(name, from string) error {
+	if err := CheckProfileName(name); err != nil {
+		return err
+	}
+	if _, err := getProfile(name); err == nil {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	if from == "" {
+		if _, err := db.Exec(`INSERT INTO profiles (name) VALUES (?)`, name); err != nil {
+			return fmt.Errorf("error creating profile %v: %v", name, err)
+		}
+		return nil
+	}
+
+	if _, err := getProfile(from); err != nil {
+		return err
+	}
+	copySQL := `INSERT INTO profiles (name, auth, orgId, projTempId, baseUrl, apiVersion, rateLimit, rateBurst) SELECT ?, auth, orgId, projTempId, baseUrl, apiVersion, rateLimit, rateBurst FROM profiles WHERE name = ?`
+	if _, err := db.Exec(copySQL, name, from); err != nil {
+		return fmt.Errorf("error copying profile %v: %v", from, err)
+	}
+	return nil
+}
+
+// DeleteProfile removes a profile, deleting the remembered one makes
+// DefaultProfile the default again
+func DeleteProfile(name string) error {
+	if _, err := getProfile(name); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM profiles WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("error deleting profile %v: %v", name, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM settings WHERE key = 'defaultProfile' AND value = ?`, name); err != nil {
+		return fmt.Errorf("error clearing default profile: %v", err)
+	}
+	return tx.Commit()
+}
+
+// RenameProfile renames a profile, keeping it the default if it was
+func RenameProfile(from, to string) error {
+	if err := CheckProfileName(to); err != nil {
+		return err
+	}
+	if _, err := getProfile(from); err != nil {
+		return err
+	}
+	if _, err := getProfile(to); err == nil {
+		return fmt.Errorf("profile %q already exists", to)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE profiles SET name = ? WHERE name = ?`, to, from); err != nil {
+		return fmt.Errorf("error renaming profile %v: %v", from, err)
+	}
+	if _, err := tx.Exec(`UPDATE settings SET value = ? WHERE key = 'defaultProfile' AND value = ?`, to, from); err != nil {
+		return fmt.Errorf("error updating default profile: %v", err)
+	}
+	return tx.Commit()
+}
+
+// ProfileNames is the name of every profile, for completions and messages
+func ProfileNames() ([]string, error) {
+	profiles, err := Profiles()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Profile
+	}
+	return names, nil
+}
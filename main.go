@@ -29,8 +29,10 @@ import (
 )
 
 func main() {
+	// commands would run against a database that's missing tables or
+	// hasn't been migrated, e.g. without the profile params were moved to
 	if err := data.InitDB("./oah.db"); err != nil {
-		log.Printf("error initialzing db: %v", err)
+		log.Fatalf("error initializing db, nothing was run: %v", err)
 	}
 	cmd.Execute()
 }
@@ -1,14 +1,94 @@
 package models
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL    = "https://lab-services.ovation.io"
+	DefaultAPIVersion = "v3"
+)
 
 type Client struct {
 	Http              *http.Client
-	OrganizationId    any
-	ProjectTemplateId any
+	OrganizationId    OrganizationId
+	ProjectTemplateId ProjectTemplateId
 	Bearer            string
+	BaseURL           string
+	APIVersion        string
+	Retry             RetryPolicy
+	// shared by every request made through the client, nil means no limit
+	Limiter Limiter
+	// verbose output goes here, nil keeps the client quiet
+	Logger *log.Logger
+}
+
+// Limiter paces requests, Wait blocks until the next one may be sent
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RetryPolicy controls how requests are retried after 429/502/503/504
+// responses and transport errors
+type RetryPolicy struct {
+	// total number of tries including the first one, 1 turns retries off
+	MaxAttempts int
+	// no retry is started once this much time has passed since the first
+	// try, 0 means no deadline
+	MaxElapsed time.Duration
+	// first backoff delay, doubled every attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// also retry methods that aren't idempotent, like POST and PATCH
+	RetryUnsafe bool
+}
+
+// DefaultRetryPolicy tries up to 4 times within 30 seconds
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MaxElapsed:  30 * time.Second,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+func NewClient(httpClient *http.Client, orgId OrganizationId, projTempId ProjectTemplateId, bearer string) *Client {
+	return &Client{
+		Http:              httpClient,
+		OrganizationId:    orgId,
+		ProjectTemplateId: projTempId,
+		Bearer:            bearer,
+		BaseURL:           DefaultBaseURL,
+		APIVersion:        DefaultAPIVersion,
+		Retry:             DefaultRetryPolicy(),
+	}
+}
+
+// URL joins path onto the client's base url and api version,
+// e.g. "/project_templates" -> "https://host/api/v3/project_templates"
+func (c *Client) URL(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + "/api/" + c.APIVersion + "/" + strings.TrimLeft(path, "/")
+}
+
+// ValidateBaseURL checks that s is an absolute http(s) url
+func ValidateBaseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid base url %q, expected something like %s", s, DefaultBaseURL)
+	}
+	return nil
 }
 
-func NewClient(httpClient *http.Client, orgId, projTempId any, bearer string) *Client {
-	return &Client{httpClient, orgId, projTempId, bearer}
+// Logf writes to the client's logger when verbose output is on
+func (c *Client) Logf(format string, v ...any) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
 }
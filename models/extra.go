@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Extra holds fields the api sent that a model has no field for, so they
+// survive a decode/encode round trip
+type Extra map[string]json.RawMessage
+
+// decodes data into v (a pointer to a struct alias without custom
+// unmarshalling) and returns the fields v has no json tag for
+func unmarshalWithExtra(data []byte, v any) (Extra, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	known := jsonNames(reflect.TypeOf(v).Elem())
+	extra := Extra{}
+	for k, raw := range all {
+		if !known[strings.ToLower(k)] {
+			extra[k] = raw
+		}
+	}
+
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// encodes v (a struct alias without custom marshalling) and adds extra's
+// fields back in. known fields win over extra ones with the same name.
+func marshalWithExtra(v any, extra Extra) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+	for k, raw := range extra {
+		if _, ok := all[k]; !ok {
+			all[k] = raw
+		}
+	}
+	return json.Marshal(all)
+}
+
+// lower cased json names of t's fields, matching encoding/json's case
+// insensitive lookup
+func jsonNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OrganizationId identifies an ovation organization
+type OrganizationId int64
+
+// ProjectTemplateId identifies a project template within an organization
+type ProjectTemplateId int64
+
+// ParseOrganizationId accepts a positive whole number like "12"
+func ParseOrganizationId(s string) (OrganizationId, error) {
+	id, err := parseId("organization id", s)
+	return OrganizationId(id), err
+}
+
+// ParseProjectTemplateId accepts a positive whole number like "345"
+func ParseProjectTemplateId(s string) (ProjectTemplateId, error) {
+	id, err := parseId("project template id", s)
+	return ProjectTemplateId(id), err
+}
+
+func (id OrganizationId) String() string    { return strconv.FormatInt(int64(id), 10) }
+func (id ProjectTemplateId) String() string { return strconv.FormatInt(int64(id), 10) }
+
+// ids decode from json numbers or numeric strings, so values read from
+// csv files and quoted yaml work the same as plain numbers
+
+func (id *OrganizationId) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalId("organization id", b)
+	*id = OrganizationId(v)
+	return err
+}
+
+func (id *ProjectTemplateId) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalId("project template id", b)
+	*id = ProjectTemplateId(v)
+	return err
+}
+
+func unmarshalId(kind string, b []byte) (int64, error) {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" || s == "" {
+		return 0, nil
+	}
+	return parseId(kind, s)
+}
+
+func parseId(kind, s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive whole number", kind, s)
+	}
+	return id, nil
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var npiPattern = regexp.MustCompile(`^[0-9]{10}$`)
+
+// RequisitionRequest is the payload for creating a requisition
+type RequisitionRequest struct {
+	RequisitionTemplateId ProjectTemplateId `json:"requisition_template_id"`
+	Identifier            string            `json:"identifier,omitempty"`
+	Patient               *PatientRequest   `json:"patient,omitempty"`
+	Provider              *ProviderRequest  `json:"provider,omitempty"`
+	Samples               []SampleRequest   `json:"samples,omitempty"`
+	CustomAttributes      map[string]any    `json:"custom_attributes,omitempty"`
+}
+
+type PatientRequest struct {
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	DateOfBirth string `json:"date_of_birth"`
+	Sex         string `json:"sex,omitempty"`
+	MRN         string `json:"mrn,omitempty"`
+}
+
+type ProviderRequest struct {
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	NPI       string `json:"npi"`
+}
+
+type SampleRequest struct {
+	Identifier  string `json:"identifier,omitempty"`
+	SampleType  string `json:"sample_type"`
+	CollectedAt string `json:"collected_at,omitempty"`
+}
+
+// Validate reports every problem with the request at once
+func (r RequisitionRequest) Validate() error {
+	var errs []string
+
+	if r.RequisitionTemplateId <= 0 {
+		errs = append(errs, "requisition_template_id is required")
+	}
+
+	if p := r.Patient; p != nil {
+		if strings.TrimSpace(p.FirstName) == "" || strings.TrimSpace(p.LastName) == "" {
+			errs = append(errs, "patient first_name and last_name are required")
+		}
+		if !validDate(p.DateOfBirth) {
+			errs = append(errs, fmt.Sprintf("patient date_of_birth %q must be YYYY-MM-DD", p.DateOfBirth))
+		}
+	}
+
+	if p := r.Provider; p != nil && !ValidNPI(p.NPI) {
+		errs = append(errs, fmt.Sprintf("provider npi %q must be 10 digits", p.NPI))
+	}
+
+	for i, s := range r.Samples {
+		if strings.TrimSpace(s.SampleType) == "" {
+			errs = append(errs, fmt.Sprintf("samples[%d].sample_type is required", i))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidNPI reports whether s looks like a national provider identifier
+func ValidNPI(s string) bool {
+	return npiPattern.MatchString(s)
+}
+
+// reports whether s is a YYYY-MM-DD calendar date
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
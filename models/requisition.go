@@ -0,0 +1,135 @@
+package models
+
+import "time"
+
+// Requisition is the full detail of one requisition, as opposed to the
+// summary in ProjectRequisition
+type Requisition struct {
+	Identifier            string           `json:"identifier"`
+	RequisitionTemplateId int              `json:"requisition_template_id"`
+	Status                string           `json:"status"`
+	AccessionStatus       AccessionStatus  `json:"accession_status"`
+	ProcessingStatus      ProcessingStatus `json:"processing_status"`
+	ReportingStatus       ReportingStatus  `json:"reporting_status"`
+	BillingStatus         BillingStatus    `json:"billing_status"`
+	Samples               []Sample         `json:"samples"`
+	Patient               *Patient         `json:"patient"`
+	Provider              *Provider        `json:"provider"`
+	CustomAttributes      map[string]any   `json:"custom_attributes"`
+	StatusHistory         []StatusChange   `json:"status_history"`
+	CreatedAt             Time             `json:"createdAt"`
+	UpdatedAt             Time             `json:"updatedAt"`
+	Extra                 Extra            `json:"-"`
+}
+
+func (r *Requisition) UnmarshalJSON(data []byte) error {
+	type alias Requisition
+	extra, err := unmarshalWithExtra(data, (*alias)(r))
+	r.Extra = extra
+	return err
+}
+
+func (r Requisition) MarshalJSON() ([]byte, error) {
+	type alias Requisition
+	return marshalWithExtra(alias(r), r.Extra)
+}
+
+type RequisitionResponse struct {
+	Requisition Requisition `json:"requisition"`
+}
+
+type Patient struct {
+	Identifier  string `json:"identifier"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	DateOfBirth string `json:"date_of_birth"`
+	Sex         string `json:"sex"`
+	MRN         string `json:"mrn"`
+	// only filled in by patient search
+	Requisitions []string `json:"requisition_identifiers,omitempty"`
+	Extra        Extra    `json:"-"`
+}
+
+func (p *Patient) UnmarshalJSON(data []byte) error {
+	type alias Patient
+	extra, err := unmarshalWithExtra(data, (*alias)(p))
+	p.Extra = extra
+	return err
+}
+
+func (p Patient) MarshalJSON() ([]byte, error) {
+	type alias Patient
+	return marshalWithExtra(alias(p), p.Extra)
+}
+
+type Provider struct {
+	Identifier string `json:"identifier"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	NPI        string `json:"npi"`
+	// only filled in by provider search
+	Requisitions []string `json:"requisition_identifiers,omitempty"`
+	Extra        Extra    `json:"-"`
+}
+
+func (p *Provider) UnmarshalJSON(data []byte) error {
+	type alias Provider
+	extra, err := unmarshalWithExtra(data, (*alias)(p))
+	p.Extra = extra
+	return err
+}
+
+func (p Provider) MarshalJSON() ([]byte, error) {
+	type alias Provider
+	return marshalWithExtra(alias(p), p.Extra)
+}
+
+type Patients struct {
+	Patients []Patient `json:"patients"`
+}
+
+type Providers struct {
+	Providers []Provider `json:"providers"`
+}
+
+// StatusChange is one entry in a requisition's status history
+type StatusChange struct {
+	Field     string    `json:"field"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type Attachment struct {
+	Id          int64     `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AttachmentResponse struct {
+	Attachment Attachment `json:"attachment"`
+}
+
+// Upload is a row of the local upload history
+type Upload struct {
+	Requisition  string
+	FilePath     string
+	ContentType  string
+	Size         int64
+	AttachmentId int64
+	UploadedAt   time.Time
+}
+
+// BillingChange is a row of the local billing audit table
+type BillingChange struct {
+	Requisition string        `json:"requisition"`
+	From        BillingStatus `json:"from"`
+	To          BillingStatus `json:"to"`
+	Error       string        `json:"error,omitempty"`
+	ChangedAt   time.Time     `json:"changedAt"`
+	// sent, or about to be, but the outcome was never recorded
+	Pending bool `json:"pending,omitempty"`
+}
@@ -1,40 +1,93 @@
 package models
 
 type DbRow struct {
+	Profile    string
 	Auth       string
-	OrgId      int
-	ProjTempId int
+	OrgId      OrganizationId
+	ProjTempId ProjectTemplateId
+	BaseURL    string
+	APIVersion string
+	RateLimit  float64
+	RateBurst  int
 }
 
 type Meta struct {
-	CurrentPage  int
-	PerPage      int
-	TotalEntries int
+	CurrentPage  int `json:"currentPage"`
+	PerPage      int `json:"perPage"`
+	TotalEntries int `json:"totalEntries"`
 }
 
 type ProjectRequisition struct {
-	Identifier              string
-	Requisition_template_id int
-	Status                  string
-	Accession_status        string
-	Processing_status       string
-	Reporting_status        string
-	Billing_status          string
-	CreatedAt               string
-	UpdatedAt               string
+	Identifier            string           `json:"identifier"`
+	RequisitionTemplateId int              `json:"requisition_template_id"`
+	Status                string           `json:"status"`
+	AccessionStatus       AccessionStatus  `json:"accession_status"`
+	ProcessingStatus      ProcessingStatus `json:"processing_status"`
+	ReportingStatus       ReportingStatus  `json:"reporting_status"`
+	BillingStatus         BillingStatus    `json:"billing_status"`
+	CreatedAt             Time             `json:"createdAt"`
+	UpdatedAt             Time             `json:"updatedAt"`
+	Extra                 Extra            `json:"-"`
+}
+
+func (r *ProjectRequisition) UnmarshalJSON(data []byte) error {
+	type alias ProjectRequisition
+	extra, err := unmarshalWithExtra(data, (*alias)(r))
+	r.Extra = extra
+	return err
+}
+
+func (r ProjectRequisition) MarshalJSON() ([]byte, error) {
+	type alias ProjectRequisition
+	return marshalWithExtra(alias(r), r.Extra)
 }
 
 type ProjectRequisitions struct {
-	Requisitions []ProjectRequisition
-	Meta         Meta
+	Requisitions []ProjectRequisition `json:"requisitions"`
+	Meta         Meta                 `json:"meta"`
 }
 
 type ProjectTemplate struct {
-	Id           int
-	ProjectName  string
-	TemplateName string
+	Id           ProjectTemplateId `json:"id"`
+	ProjectName  string            `json:"projectName"`
+	TemplateName string            `json:"templateName"`
+	Extra        Extra             `json:"-"`
+}
+
+func (t *ProjectTemplate) UnmarshalJSON(data []byte) error {
+	type alias ProjectTemplate
+	extra, err := unmarshalWithExtra(data, (*alias)(t))
+	t.Extra = extra
+	return err
+}
+
+func (t ProjectTemplate) MarshalJSON() ([]byte, error) {
+	type alias ProjectTemplate
+	return marshalWithExtra(alias(t), t.Extra)
 }
 
 type ProjectTemplates struct {
-	Project_Templates []ProjectTemplate
+	ProjectTemplates []ProjectTemplate `json:"project_templates"`
+}
+
+type Organization struct {
+	Id    OrganizationId `json:"id"`
+	Name  string         `json:"name"`
+	Extra Extra          `json:"-"`
+}
+
+func (o *Organization) UnmarshalJSON(data []byte) error {
+	type alias Organization
+	extra, err := unmarshalWithExtra(data, (*alias)(o))
+	o.Extra = extra
+	return err
+}
+
+func (o Organization) MarshalJSON() ([]byte, error) {
+	type alias Organization
+	return marshalWithExtra(alias(o), o.Extra)
+}
+
+type Organizations struct {
+	Organizations []Organization `json:"organizations"`
 }
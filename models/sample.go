@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+type Sample struct {
+	Identifier  string     `json:"identifier"`
+	Barcode     string     `json:"barcode"`
+	Requisition string     `json:"requisition_identifier"`
+	SampleType  string     `json:"sample_type"`
+	Status      string     `json:"status"`
+	Container   *Container `json:"container"`
+	CollectedAt *time.Time `json:"collected_at"`
+	ReceivedAt  *time.Time `json:"received_at"`
+	Extra       Extra      `json:"-"`
+}
+
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	type alias Sample
+	extra, err := unmarshalWithExtra(data, (*alias)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s Sample) MarshalJSON() ([]byte, error) {
+	type alias Sample
+	return marshalWithExtra(alias(s), s.Extra)
+}
+
+// Location is where the sample's container currently is, empty when the
+// sample hasn't been stored yet
+func (s Sample) Location() string {
+	if s.Container == nil {
+		return ""
+	}
+	return s.Container.Location()
+}
+
+// Container is the tube, plate or box a sample is held in
+type Container struct {
+	Identifier    string `json:"identifier"`
+	Barcode       string `json:"barcode"`
+	ContainerType string `json:"container_type"`
+	Storage       string `json:"storage_location"`
+	Position      string `json:"position"`
+	Extra         Extra  `json:"-"`
+}
+
+func (c *Container) UnmarshalJSON(data []byte) error {
+	type alias Container
+	extra, err := unmarshalWithExtra(data, (*alias)(c))
+	c.Extra = extra
+	return err
+}
+
+func (c Container) MarshalJSON() ([]byte, error) {
+	type alias Container
+	return marshalWithExtra(alias(c), c.Extra)
+}
+
+// Location joins the storage location and position, e.g. "Freezer 2 / A3"
+func (c Container) Location() string {
+	switch {
+	case c.Storage == "":
+		return c.Position
+	case c.Position == "":
+		return c.Storage
+	}
+	return c.Storage + " / " + c.Position
+}
+
+type SampleResponse struct {
+	Sample Sample `json:"sample"`
+}
+
+type Samples struct {
+	Samples []Sample `json:"samples"`
+}
+
+type ContainerResponse struct {
+	Container Container `json:"container"`
+}
@@ -0,0 +1,79 @@
+package models
+
+// statuses the api reports for each stage of a requisition. values the
+// api adds later still decode, Known reports whether a value is one of ours.
+
+type AccessionStatus string
+
+const (
+	AccessionPending     AccessionStatus = "pending"
+	AccessionAccessioned AccessionStatus = "accessioned"
+	AccessionRejected    AccessionStatus = "rejected"
+	AccessionOnHold      AccessionStatus = "on_hold"
+)
+
+func (s AccessionStatus) Known() bool {
+	switch s {
+	case AccessionPending, AccessionAccessioned, AccessionRejected, AccessionOnHold:
+		return true
+	}
+	return false
+}
+
+type ProcessingStatus string
+
+const (
+	ProcessingPending    ProcessingStatus = "pending"
+	ProcessingInProgress ProcessingStatus = "in_progress"
+	ProcessingComplete   ProcessingStatus = "complete"
+	ProcessingFailed     ProcessingStatus = "failed"
+)
+
+func (s ProcessingStatus) Known() bool {
+	switch s {
+	case ProcessingPending, ProcessingInProgress, ProcessingComplete, ProcessingFailed:
+		return true
+	}
+	return false
+}
+
+type ReportingStatus string
+
+const (
+	ReportingPending     ReportingStatus = "pending"
+	ReportingPreliminary ReportingStatus = "preliminary"
+	ReportingFinal       ReportingStatus = "final"
+	ReportingAmended     ReportingStatus = "amended"
+)
+
+func (s ReportingStatus) Known() bool {
+	switch s {
+	case ReportingPending, ReportingPreliminary, ReportingFinal, ReportingAmended:
+		return true
+	}
+	return false
+}
+
+type BillingStatus string
+
+const (
+	BillingPending   BillingStatus = "pending"
+	BillingSubmitted BillingStatus = "submitted"
+	BillingBilled    BillingStatus = "billed"
+	BillingPaid      BillingStatus = "paid"
+	BillingDenied    BillingStatus = "denied"
+	BillingOnHold    BillingStatus = "on_hold"
+)
+
+func (s BillingStatus) Known() bool {
+	switch s {
+	case BillingPending, BillingSubmitted, BillingBilled, BillingPaid, BillingDenied, BillingOnHold:
+		return true
+	}
+	return false
+}
+
+// BillingStatuses lists the known billing statuses in workflow order
+func BillingStatuses() []BillingStatus {
+	return []BillingStatus{BillingPending, BillingSubmitted, BillingBilled, BillingPaid, BillingDenied, BillingOnHold}
+}
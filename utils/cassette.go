@@ -0,0 +1,404 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const redacted = "REDACTED"
+
+// headers that never get written to a cassette as is
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// json keys holding patient information, compared lower case with
+// underscores and dashes removed
+var phiKeys = map[string]bool{
+	"firstname": true, "lastname": true, "middlename": true, "fullname": true,
+	"patientname": true, "dob": true, "dateofbirth": true, "birthdate": true,
+	"ssn": true, "mrn": true, "medicalrecordnumber": true, "phone": true,
+	"phonenumber": true, "email": true, "address": true, "address1": true,
+	"address2": true, "street": true, "city": true, "zip": true, "zipcode": true,
+	"postalcode": true, "insuranceid": true, "memberid": true, "policynumber": true,
+}
+
+// Cassette is a recording of http interactions that can be replayed later
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string        `json:"method"`
+	URL    string        `json:"url"`
+	Header http.Header   `json:"header,omitempty"`
+	Body   *RecordedBody `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int           `json:"statusCode"`
+	Header     http.Header   `json:"header,omitempty"`
+	Body       *RecordedBody `json:"body,omitempty"`
+}
+
+// RecordedBody keeps text bodies readable in the cassette file and falls
+// back to base64 for binary ones like pdfs
+type RecordedBody struct {
+	Text   string `json:"text,omitempty"`
+	Base64 string `json:"base64,omitempty"`
+}
+
+func newRecordedBody(b []byte) *RecordedBody {
+	if len(b) == 0 {
+		return nil
+	}
+	if utf8.Valid(b) {
+		return &RecordedBody{Text: string(b)}
+	}
+	return &RecordedBody{Base64: base64.StdEncoding.EncodeToString(b)}
+}
+
+func (b *RecordedBody) bytes() ([]byte, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if b.Base64 != "" {
+		return base64.StdEncoding.DecodeString(b.Base64)
+	}
+	return []byte(b.Text), nil
+}
+
+// LoadCassette reads a cassette written by a RecordingTransport
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// RecordingTransport passes requests on to Base and saves every exchange,
+// with secrets and phi redacted, to the cassette file at Path
+type RecordingTransport struct {
+	Base http.RoundTripper
+	Path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func NewRecordingTransport(base http.RoundTripper, path string) *RecordingTransport {
+	return &RecordingTransport{Base: base, Path: path}
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, err = io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: redactHeader(req.Header),
+			Body:   newRecordedBody(redactBody(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Header:     redactHeader(res.Header),
+			Body:       newRecordedBody(redactBody(resBody)),
+		},
+	}
+
+	if err := t.save(interaction); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// appends an interaction and rewrites the whole file so a cassette is
+// usable even if the program is killed mid run
+func (t *RecordingTransport) save(i Interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cassette.Interactions = append(t.cassette.Interactions, i)
+
+	b, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.Path, b, 0600); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport answers requests from a cassette without touching the
+// network. recorded interactions are matched on method, path and query, see
+// replayKey, and used in order, the last match is reused once they run out.
+// a requisition list over the default date range also matches one recorded
+// over the default range of an earlier day, any other dates have to match
+// exactly, so a cassette recorded with other --since/--until values has to
+// be recorded again.
+type ReplayTransport struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+func NewReplayTransport(c *Cassette) *ReplayTransport {
+	return &ReplayTransport{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	i, ok := t.match(replayKey(req.Method, req.URL.String()), false)
+	if !ok && isDefaultRange(req.URL.Query(), time.Now()) {
+		i, ok = t.match(undatedKey(req.Method, req.URL.String()), true)
+	}
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, redactURL(req.URL))
+	}
+
+	body, err := i.Response.Body.bytes()
+	if err != nil {
+		return nil, fmt.Errorf("corrupt recorded body for %s %s: %w", req.Method, redactURL(req.URL), err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Response.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// undated matches recordings made over the default date range by their
+// undatedKey instead
+func (t *ReplayTransport) match(key string, undated bool) (Interaction, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last := -1
+	for n, i := range t.cassette.Interactions {
+		recorded := replayKey(i.Request.Method, i.Request.URL)
+		if undated {
+			if !wasDefaultRange(i.Request.URL) {
+				continue
+			}
+			recorded = undatedKey(i.Request.Method, i.Request.URL)
+		}
+		if recorded != key {
+			continue
+		}
+		if !t.used[n] {
+			t.used[n] = true
+			return i, true
+		}
+		last = n
+	}
+
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return t.cassette.Interactions[last], true
+}
+
+// date range params of requisition lists, left out of the key when the
+// default range is replayed on a later day
+var dateParams = []string{"startDate", "endDate"}
+
+// replayKey identifies a request for replay by method, path and sorted
+// query. phi values are redacted the way they are in recordings and the
+// host is ignored so --base-url doesn't matter.
+func replayKey(method, rawURL string) string {
+	return queryKey(method, rawURL, nil)
+}
+
+// undatedKey is replayKey without the date range
+func undatedKey(method, rawURL string) string {
+	return queryKey(method, rawURL, dateParams)
+}
+
+func queryKey(method, rawURL string, drop []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+
+	query := u.Query()
+	for k := range query {
+		if isPHIKey(k) {
+			query[k] = []string{redacted}
+		}
+	}
+	for _, k := range drop {
+		query.Del(k)
+	}
+	return method + " " + u.Path + "?" + query.Encode()
+}
+
+// true when the query asks for DefaultDateRange as of now, i.e. no
+// --since/--until were given
+func isDefaultRange(query url.Values, now time.Time) bool {
+	def := DefaultDateRange(now)
+	return query.Get("startDate") == def.StartDate() && query.Get("endDate") == def.EndDate()
+}
+
+// true when a recorded request asked for the default range of the day it
+// was recorded on
+func wasDefaultRange(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	query := u.Query()
+
+	since, err := time.Parse(apiDateLayout, query.Get("startDate"))
+	if err != nil {
+		return false
+	}
+	until, err := time.Parse(apiDateLayout, query.Get("endDate"))
+	if err != nil {
+		return false
+	}
+	return DefaultDateRange(until).Since.Equal(since)
+}
+
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	out := h.Clone()
+	// bodies can change size once redacted
+	out.Del("Content-Length")
+	for _, name := range secretHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// redactURL is u with the values of phi query parameters, like a patient
+// search's last_name, replaced
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for k := range query {
+		if isPHIKey(k) {
+			query[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+
+	out := *u
+	out.RawQuery = query.Encode()
+	return out.String()
+}
+
+func redactRawURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return redactURL(u)
+}
+
+// replaces phi values in json bodies, anything else is returned unchanged
+func redactBody(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if isPHIKey(k) && val != nil {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(val)
+		}
+		return v
+	case []any:
+		for n := range v {
+			v[n] = redactValue(v[n])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isPHIKey(k string) bool {
+	k = strings.ToLower(k)
+	k = strings.NewReplacer("_", "", "-", "").Replace(k)
+	return phiKeys[k]
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestReplayDateRange(t *testing.T) {
+	now := time.Now()
+	today := DefaultDateRange(now)
+	earlier := DefaultDateRange(now.AddDate(0, 0, -3))
+	custom := DateRange{Since: today.Since.AddDate(0, 0, -60), Until: today.Until.AddDate(0, 0, -10)}
+
+	list := func(r DateRange) string {
+		query := url.Values{}
+		query.Set("startDate", r.StartDate())
+		query.Set("endDate", r.EndDate())
+		query.Set("page", "1")
+		return "https://example.com/api/v3/project_templates/1/requisitions?" + query.Encode()
+	}
+
+	tests := []struct {
+		name     string
+		recorded DateRange
+		request  DateRange
+		ok       bool
+	}{
+		{"same default range", today, today, true},
+		{"default range of an earlier day", earlier, today, true},
+		{"same custom range", custom, custom, true},
+		{"custom range against a default recording", earlier, custom, false},
+		{"default range against a custom recording", custom, today, false},
+		{"other custom range", custom, DateRange{Since: custom.Since, Until: custom.Until.AddDate(0, 0, 1)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cassette := &Cassette{Interactions: []Interaction{{
+				Request:  RecordedRequest{Method: http.MethodGet, URL: list(tt.recorded)},
+				Response: RecordedResponse{StatusCode: http.StatusOK},
+			}}}
+
+			req, err := http.NewRequest(http.MethodGet, list(tt.request), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = NewReplayTransport(cassette).RoundTrip(req)
+			if ok := err == nil; ok != tt.ok {
+				t.Fatalf("replayed = %v, want %v: %v", ok, tt.ok, err)
+			}
+		})
+	}
+}
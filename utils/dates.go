@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// date format the api expects for startDate/endDate
+const apiDateLayout = "01-02-2006"
+
+// layouts accepted for absolute dates on the command line and in the tui
+var dateLayouts = []string{"2006-01-02", "01-02-2006", "01/02/2006"}
+
+// default lookback when no --since is given
+const defaultRangeDays = 30
+
+// DateRange bounds a requisition query, both days are inclusive
+type DateRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// DefaultDateRange covers the last 30 days up to today
+func DefaultDateRange(now time.Time) DateRange {
+	today := day(now)
+	return DateRange{Since: today.AddDate(0, 0, -defaultRangeDays), Until: today}
+}
+
+// ParseDateRange validates since/until values and turns them into a range.
+// empty values fall back to DefaultDateRange.
+func ParseDateRange(since, until string, now time.Time) (DateRange, error) {
+	r := DefaultDateRange(now)
+
+	if strings.TrimSpace(until) != "" {
+		t, err := parseDate(until, now, true)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("invalid until date: %w", err)
+		}
+		r.Until = t
+		if strings.TrimSpace(since) == "" {
+			r.Since = t.AddDate(0, 0, -defaultRangeDays)
+		}
+	}
+
+	if strings.TrimSpace(since) != "" {
+		t, err := parseDate(since, now, false)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("invalid since date: %w", err)
+		}
+		r.Since = t
+	}
+
+	if r.Since.After(r.Until) {
+		return DateRange{}, fmt.Errorf("since date %s is after until date %s", r.Since.Format("2006-01-02"), r.Until.Format("2006-01-02"))
+	}
+
+	return r, nil
+}
+
+// StartDate returns the start of the range in the api's MM-DD-YYYY format
+func (r DateRange) StartDate() string { return r.Since.Format(apiDateLayout) }
+
+// EndDate returns the end of the range in the api's MM-DD-YYYY format
+func (r DateRange) EndDate() string { return r.Until.Format(apiDateLayout) }
+
+func (r DateRange) String() string {
+	return r.Since.Format("2006-01-02") + " to " + r.Until.Format("2006-01-02")
+}
+
+// parseDate understands absolute dates plus relative ones like "7d", "2w",
+// "3m", "1y", "today", "yesterday", "this-week", "last-month" etc.
+// named periods resolve to their first day, or their last day when end is set.
+func parseDate(s string, now time.Time, end bool) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	today := day(now)
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	switch s {
+	case "today", "now":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "this-week", "last-week":
+		// weeks start on monday
+		start := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		if s == "last-week" {
+			start = start.AddDate(0, 0, -7)
+		}
+		return period(start, start.AddDate(0, 0, 7), end), nil
+	case "this-month", "last-month":
+		start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		if s == "last-month" {
+			start = start.AddDate(0, -1, 0)
+		}
+		return period(start, start.AddDate(0, 1, 0), end), nil
+	case "this-year", "last-year":
+		start := time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+		if s == "last-year" {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return period(start, start.AddDate(1, 0, 0), end), nil
+	}
+
+	// relative offsets back from today, e.g. 7d
+	if len(s) >= 2 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n >= 0 {
+			switch s[len(s)-1] {
+			case 'd':
+				return today.AddDate(0, 0, -n), nil
+			case 'w':
+				return today.AddDate(0, 0, -7*n), nil
+			case 'm':
+				return today.AddDate(0, -n, 0), nil
+			case 'y':
+				return today.AddDate(-n, 0, 0), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date, use YYYY-MM-DD, MM-DD-YYYY or a relative value like 7d, yesterday, this-month", s)
+}
+
+// first day of [start, next) or the last one when end is set
+func period(start, next time.Time, end bool) time.Time {
+	if end {
+		return next.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// truncates t to midnight in its own location
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// ParseDate parses an absolute date in one of the accepted layouts,
+// relative values aren't allowed
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, use YYYY-MM-DD, MM-DD-YYYY or MM/DD/YYYY", s)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeDocuments reads yaml or json (yaml is a superset of it) and returns
+// every document as json. multiple yaml documents separated by "---" and
+// top level lists are both split into one entry per item.
+func DecodeDocuments(r io.Reader) ([]json.RawMessage, error) {
+	dec := yaml.NewDecoder(r)
+	var docs []json.RawMessage
+
+	for n := 1; ; n++ {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("document %d: %w", n, err)
+		}
+		if v == nil {
+			continue
+		}
+
+		items := []any{v}
+		if list, ok := v.([]any); ok {
+			items = list
+		}
+
+		for _, item := range items {
+			b, err := json.Marshal(jsonValue(item))
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %w", n, err)
+			}
+			docs = append(docs, b)
+		}
+	}
+}
+
+// DecodeStrict unmarshals one json document into v, rejecting fields v
+// doesn't have so typos in input files don't get silently dropped
+func DecodeStrict(doc json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// converts what the yaml decoder produces into values encoding/json
+// can handle and that mean the same thing
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = jsonValue(val)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = jsonValue(val)
+		}
+		return m
+	case []any:
+		for i := range v {
+			v[i] = jsonValue(v[i])
+		}
+		return v
+	case time.Time:
+		// unquoted yaml dates like 1980-01-01 come back as timestamps
+		if v.Equal(time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, v.Location())) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return v
+	}
+}
+
+// ParseScalar reads a command line value the way yaml would, so 5 is a
+// number, true a bool, null nil and anything else a string
+func ParseScalar(s string) any {
+	if s == "" {
+		return s
+	}
+
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	switch v.(type) {
+	case map[string]any, map[any]any, []any:
+		// only scalars, "a: b" is meant as a string
+		return s
+	}
+	return jsonValue(v)
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// max number of response body bytes kept on an APIError
+const bodyExcerptLen = 512
+
+// APIError is returned when the api answers with a non-2xx status code
+type APIError struct {
+	StatusCode int
+	Endpoint   string
+	RequestId  string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: %d %s", e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.RequestId != "" {
+		msg += fmt.Sprintf(" (request id %s)", e.RequestId)
+	}
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// TransportError is returned when no response was received at all,
+// e.g. dns failures, refused connections or timeouts
+type TransportError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%s: request failed: %v", e.Endpoint, e.Err)
+}
+
+func (e *TransportError) Unwrap() error { return e.Err }
+
+// DecodeError is returned when a successful response can't be decoded
+type DecodeError struct {
+	StatusCode int
+	Endpoint   string
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: malformed response: %v", e.Endpoint, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// IsUnauthorized reports whether err means the token was rejected
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// IsStale reports whether err is the api refusing a write because its
+// precondition failed, i.e. the resource changed since it was read
+func IsStale(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// IsNotFound reports whether err is a 404 from the api
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -1,57 +1,305 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sabino-ramirez/oah/models"
 )
 
-func GetProjectRequisitions(client *models.Client, target interface{}) (int, error) {
-	url := fmt.Sprintf("https://lab-services.ovation.io/api/v3/project_templates/%d/requisitions?startDate=01-01-2020&endDate=01-01-2021", client.ProjectTemplateId)
+// do sends a request through the client and decodes a json response into
+// target. target may be nil when the response body isn't needed.
+func do(ctx context.Context, client *models.Client, method, reqURL string, body []byte, target any) error {
+	return doWithHeader(ctx, client, method, reqURL, body, nil, target)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// doWithHeader is do with extra request headers, see send
+func doWithHeader(ctx context.Context, client *models.Client, method, reqURL string, body []byte, header http.Header, target any) error {
+	res, err := send(ctx, client, method, reqURL, body, header)
 	if err != nil {
-		log.Println("new req error:", err)
+		return err
+	}
+	return decode(res, method, reqURL, target)
+}
+
+// reads and closes a response body, decoding it into target unless it's nil
+func decode(res *http.Response, method, reqURL string, target any) error {
+	defer res.Body.Close()
+
+	if target == nil {
+		_, err := io.Copy(io.Discard, res.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(target); err != nil {
+		return &DecodeError{StatusCode: res.StatusCode, Endpoint: endpoint(method, reqURL), Err: err}
+	}
+
+	return nil
+}
+
+// send performs a request, retrying it according to the client's retry
+// policy. a 2xx response is returned with its body open for the caller to
+// read and close, anything else comes back as an error. header values
+// replace the json defaults.
+func send(ctx context.Context, client *models.Client, method, reqURL string, body []byte, header http.Header) (*http.Response, error) {
+	var newBody bodyFunc
+	if body != nil {
+		newBody = func() (io.Reader, int64, error) {
+			return bytes.NewReader(body), int64(len(body)), nil
+		}
+	}
+	return sendBody(ctx, client, method, reqURL, newBody, header)
+}
+
+// bodyFunc gives a request body from the start along with its length, it's
+// called again for every retry
+type bodyFunc func() (io.Reader, int64, error)
+
+// sendBody is send for bodies that aren't held in memory, like uploads
+// streamed from a file. newBody may be nil for requests without a body.
+func sendBody(ctx context.Context, client *models.Client, method, reqURL string, newBody bodyFunc, header http.Header) (*http.Response, error) {
+	started := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		if client.Limiter != nil {
+			if err := client.Limiter.Wait(ctx); err != nil {
+				return nil, &TransportError{Endpoint: endpoint(method, reqURL), Err: err}
+			}
+		}
+
+		var reqBody io.Reader
+		size := int64(0)
+		if newBody != nil {
+			var err error
+			if reqBody, size, err = newBody(); err != nil {
+				return nil, fmt.Errorf("reading body for %s: %w", endpoint(method, reqURL), err)
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("building request for %s: %w", reqURL, err)
+		}
+		authorize(req, client)
+		if newBody != nil {
+			req.ContentLength = size
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		res, err := client.Http.Do(req)
+		err = redactURLError(err)
+		if err == nil && res.StatusCode >= 200 && res.StatusCode <= 299 {
+			return res, nil
+		}
+
+		wait, retry := nextRetry(ctx, client.Retry, method, attempt, started, res, err)
+		if !retry {
+			if err != nil {
+				return nil, &TransportError{Endpoint: endpoint(method, reqURL), Err: err}
+			}
+			apiErr := newAPIError(method, reqURL, res)
+			res.Body.Close()
+			return nil, apiErr
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = res.Status
+			io.Copy(io.Discard, io.LimitReader(res.Body, bodyExcerptLen))
+			res.Body.Close()
+		}
+		client.Logf("retrying %s after %s in %s (attempt %d of %d)", endpoint(method, reqURL), reason, wait.Round(time.Millisecond), attempt+1, client.Retry.MaxAttempts)
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, &TransportError{Endpoint: endpoint(method, reqURL), Err: err}
+		}
+	}
+}
+
+// sets the headers every api request needs
+func authorize(req *http.Request, client *models.Client) {
+	req.Header.Set("Authorization", client.Bearer)
+	req.Header.Set("Accept", "application/json")
+}
+
+// builds an APIError out of a non-2xx response
+func newAPIError(method, reqURL string, res *http.Response) *APIError {
+	excerpt, _ := io.ReadAll(io.LimitReader(res.Body, bodyExcerptLen))
+
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Endpoint:   endpoint(method, reqURL),
+		RequestId:  res.Header.Get("X-Request-Id"),
+		Body:       strings.TrimSpace(string(excerpt)),
+	}
+}
+
+// endpoints end up in errors and logs, so phi in the query is redacted
+func endpoint(method, reqURL string) string {
+	return method + " " + redactRawURL(reqURL)
+}
+
+// the *url.Error from a failed request repeats the full url
+func redactURLError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		out := *ue
+		out.URL = redactRawURL(ue.URL)
+		return &out
+	}
+	return err
+}
+
+// GetProjectRequisitions fetches the first page of requisitions, use
+// EachProjectRequisition or ListProjectRequisitions to get all of them
+func GetProjectRequisitions(ctx context.Context, client *models.Client, dates DateRange, target interface{}) error {
+	return getProjectRequisitionsPage(ctx, client, dates, 1, 0, target)
+}
+
+func getProjectRequisitionsPage(ctx context.Context, client *models.Client, dates DateRange, page, perPage int, target interface{}) error {
+	query := url.Values{}
+	query.Set("startDate", dates.StartDate())
+	query.Set("endDate", dates.EndDate())
+	query.Set("page", strconv.Itoa(page))
+	if perPage > 0 {
+		query.Set("perPage", strconv.Itoa(perPage))
 	}
-	req.Header.Add("Authorization", client.Bearer)
 
-	res, err := client.Http.Do(req)
+	reqURL := client.URL("/project_templates/" + client.ProjectTemplateId.String() + "/requisitions?" + query.Encode())
+
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+func GetProjectTemplates(ctx context.Context, client *models.Client, target interface{}) error {
+	reqURL := client.URL("/project_templates?organizationId=" + client.OrganizationId.String())
+
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+// DownloadReport starts downloading a requisition's final report pdf. the
+// caller reads and closes the returned response body, its ContentLength
+// is -1 when the size isn't known up front.
+func DownloadReport(ctx context.Context, client *models.Client, identifier string) (*http.Response, error) {
+	reqURL := client.URL("/requisitions/" + url.PathEscape(identifier) + "/report")
+
+	return send(ctx, client, http.MethodGet, reqURL, nil, http.Header{"Accept": {"application/pdf"}})
+}
+
+// ReportSize asks for the size of a requisition's final report pdf without
+// downloading it. it's -1 when the server doesn't say or doesn't answer
+// HEAD requests.
+func ReportSize(ctx context.Context, client *models.Client, identifier string) (int64, error) {
+	reqURL := client.URL("/requisitions/" + url.PathEscape(identifier) + "/report")
+
+	res, err := send(ctx, client, http.MethodHead, reqURL, nil, http.Header{"Accept": {"application/pdf"}})
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusMethodNotAllowed || apiErr.StatusCode == http.StatusNotImplemented) {
+		return -1, nil
+	}
 	if err != nil {
-		log.Println("response error:", err)
+		return 0, err
 	}
+	res.Body.Close()
 
-	defer res.Body.Close()
+	return res.ContentLength, nil
+}
 
-	return res.StatusCode, json.NewDecoder(res.Body).Decode(target)
+// GetOrganizations lists the organizations the client's token can access
+func GetOrganizations(ctx context.Context, client *models.Client, target interface{}) error {
+	return do(ctx, client, http.MethodGet, client.URL("/organizations"), nil, target)
 }
 
-func GetProjectTemplates(client *models.Client, target interface{}) (int, error) {
-	url := fmt.Sprintf("https://lab-services.ovation.io/api/v3/project_templates?organizationId=%d", client.OrganizationId)
+// GetRequisition fetches the full detail of one requisition
+func GetRequisition(ctx context.Context, client *models.Client, identifier string, target interface{}) error {
+	reqURL := client.URL("/requisitions/" + url.PathEscape(identifier))
 
-	req, err := http.NewRequest("GET", url, nil)
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+// CreateRequisition posts a new requisition, the created one is decoded
+// into target
+func CreateRequisition(ctx context.Context, client *models.Client, req models.RequisitionRequest, target interface{}) error {
+	body, err := json.Marshal(RequisitionPayload(req))
 	if err != nil {
-		log.Println("new req error:", err)
+		return err
 	}
-	req.Header.Add("Authorization", client.Bearer)
 
-	res, err := client.Http.Do(req)
+	return do(ctx, client, http.MethodPost, client.URL("/requisitions"), body, target)
+}
+
+// RequisitionPayload wraps a request the way the api expects it
+func RequisitionPayload(req models.RequisitionRequest) any {
+	return map[string]any{"requisition": req}
+}
+
+// UpdateRequisition patches the given fields of a requisition, the updated
+// requisition is decoded into target
+func UpdateRequisition(ctx context.Context, client *models.Client, identifier string, fields map[string]any, target interface{}) error {
+	body, err := json.Marshal(map[string]any{"requisition": fields})
 	if err != nil {
-		log.Println("response error:", err)
+		return err
 	}
 
-	defer res.Body.Close()
+	return do(ctx, client, http.MethodPatch, client.URL("/requisitions/"+url.PathEscape(identifier)), body, target)
+}
 
-	if res.StatusCode == 404 {
-		fmt.Printf("The response is 404 which means project templates for orgId: %d doesn't exist. \nTry different one?\n", client.OrganizationId)
-		// body, err := ioutil.ReadAll(res.Body)
-		// if err != nil {
-		//   return err
-		// }
-		// log.Println(string([]byte(body)))
+// UpdateRequisitionIfUnmodified is UpdateRequisition with an
+// If-Unmodified-Since precondition, an api that supports it refuses the
+// patch with 412 if the requisition changed after updatedAt, see IsStale.
+// the header only has whole seconds so updatedAt is rounded up, a change
+// later in the same second has to be caught by the caller.
+func UpdateRequisitionIfUnmodified(ctx context.Context, client *models.Client, identifier string, fields map[string]any, updatedAt time.Time, target interface{}) error {
+	body, err := json.Marshal(map[string]any{"requisition": fields})
+	if err != nil {
+		return err
 	}
 
-	return res.StatusCode, json.NewDecoder(res.Body).Decode(target)
+	since := updatedAt.UTC().Truncate(time.Second)
+	if since.Before(updatedAt) {
+		since = since.Add(time.Second)
+	}
+
+	header := http.Header{"If-Unmodified-Since": {since.Format(http.TimeFormat)}}
+	return doWithHeader(ctx, client, http.MethodPatch, client.URL("/requisitions/"+url.PathEscape(identifier)), body, header, target)
+}
+
+// GetRequisitionSamples lists the samples on a requisition along with
+// their containers
+func GetRequisitionSamples(ctx context.Context, client *models.Client, identifier string, target interface{}) error {
+	reqURL := client.URL("/requisitions/" + url.PathEscape(identifier) + "/samples")
+
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+// GetSampleByBarcode looks up one sample by the barcode on its tube
+func GetSampleByBarcode(ctx context.Context, client *models.Client, barcode string, target interface{}) error {
+	reqURL := client.URL("/samples/barcode/" + url.PathEscape(barcode))
+
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+// GetContainer fetches one container by identifier
+func GetContainer(ctx context.Context, client *models.Client, identifier string, target interface{}) error {
+	reqURL := client.URL("/containers/" + url.PathEscape(identifier))
+
+	return do(ctx, client, http.MethodGet, reqURL, nil, target)
+}
+
+// UpdateBillingStatus moves a requisition to a new billing status
+func UpdateBillingStatus(ctx context.Context, client *models.Client, identifier string, status models.BillingStatus, target interface{}) error {
+	return UpdateRequisition(ctx, client, identifier, map[string]any{"billing_status": status}, target)
 }
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LogLevel picks how much the LoggingTransport writes per request
+type LogLevel int
+
+const (
+	LogOff LogLevel = iota
+	// method, url, status, latency and size
+	LogVerbose
+	// everything in LogVerbose plus headers and bodies
+	LogTrace
+)
+
+const masked = "****"
+
+// LoggingTransport logs every request passing through it. the Authorization
+// header and any of the Secrets values are masked, and phi in urls and json
+// bodies redacted, before anything is written.
+type LoggingTransport struct {
+	Base    http.RoundTripper
+	Logger  *log.Logger
+	Level   LogLevel
+	Secrets []string
+}
+
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Level == LogOff || t.Logger == nil {
+		return t.Base.RoundTrip(req)
+	}
+
+	if t.Level >= LogTrace {
+		if dump, err := httputil.DumpRequestOut(maskRequest(req), true); err == nil {
+			t.Logger.Printf("request:\n%s", t.mask(string(dump)))
+		}
+	}
+
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		t.Logger.Printf("%s %s failed after %s: %v", req.Method, t.mask(redactURL(req.URL)), latency, err)
+		return nil, err
+	}
+
+	if t.Level >= LogTrace {
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+
+		if dump, err := httputil.DumpResponse(maskResponse(res, body), true); err == nil {
+			t.Logger.Printf("response:\n%s", t.mask(string(dump)))
+		}
+	}
+
+	// without a Content-Length the size is only known once the caller has
+	// read the body, callers that stop early log what they read
+	res.Body = &loggedBody{
+		ReadCloser: res.Body,
+		done: func(n int64) {
+			if res.ContentLength >= 0 {
+				n = res.ContentLength
+			}
+			t.Logger.Printf("%s %s %s %s %dB", req.Method, t.mask(redactURL(req.URL)), res.Status, latency, n)
+		},
+	}
+
+	return res, nil
+}
+
+// shorter secrets, like the placeholder token setup stores, would mask
+// unrelated parts of the output
+const minSecretLen = 8
+
+func (t *LoggingTransport) mask(s string) string {
+	for _, secret := range t.Secrets {
+		if len(secret) >= minSecretLen {
+			s = strings.ReplaceAll(s, secret, masked)
+		}
+	}
+	return s
+}
+
+// copy of req with the Authorization header masked and phi redacted, the
+// body is left readable for the real round trip. bodies that can't be read
+// twice, like streamed uploads, are replaced by a placeholder.
+func maskRequest(req *http.Request) *http.Request {
+	out := req.Clone(req.Context())
+	if out.Header.Get("Authorization") != "" {
+		scheme, _, _ := strings.Cut(out.Header.Get("Authorization"), " ")
+		out.Header.Set("Authorization", scheme+" "+masked)
+	}
+	if u, err := url.Parse(redactURL(req.URL)); err == nil {
+		out.URL = u
+	}
+
+	out.Body = nil
+	if req.Body == nil || req.Body == http.NoBody {
+		out.ContentLength = 0
+		return out
+	}
+
+	b := streamedBody(req.ContentLength)
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			copied, err := io.ReadAll(body)
+			body.Close()
+			if err == nil {
+				b = redactBody(copied)
+			}
+		}
+	}
+	out.Body = io.NopCloser(bytes.NewReader(b))
+	out.ContentLength = int64(len(b))
+	return out
+}
+
+func streamedBody(size int64) []byte {
+	if size < 0 {
+		return []byte("[streamed body]")
+	}
+	return []byte(fmt.Sprintf("[streamed body, %d bytes]", size))
+}
+
+// copy of res for dumping with phi in its json body redacted
+func maskResponse(res *http.Response, body []byte) *http.Response {
+	out := *res
+	b := redactBody(body)
+	out.Body = io.NopCloser(bytes.NewReader(b))
+	out.ContentLength = int64(len(b))
+	out.Header = res.Header.Clone()
+	out.Header.Del("Content-Length")
+	return &out
+}
+
+// counts bytes read from a response body and reports them on close
+type loggedBody struct {
+	io.ReadCloser
+	n      int64
+	done   func(n int64)
+	closed bool
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.done(b.n)
+	}
+	return b.ReadCloser.Close()
+}
+
+// ParseLogLevel maps the -v/--trace flags onto a LogLevel
+func ParseLogLevel(verbose, trace bool) LogLevel {
+	switch {
+	case trace:
+		return LogTrace
+	case verbose:
+		return LogVerbose
+	default:
+		return LogOff
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// ErrStopPaging can be returned from a page callback to stop walking pages
+// early without it being reported as an error
+var ErrStopPaging = errors.New("stop paging")
+
+// PageOptions controls how list endpoints are walked
+type PageOptions struct {
+	// number of items asked for per request, 0 leaves it to the api
+	PerPage int
+	// stop after this many items, 0 means no cap
+	MaxItems int
+}
+
+// RequisitionQuery selects which requisitions list calls return
+type RequisitionQuery struct {
+	Range DateRange
+	PageOptions
+}
+
+// EachProjectRequisition walks every page of requisitions for the client's
+// project template and calls fn once per requisition
+func EachProjectRequisition(ctx context.Context, client *models.Client, query RequisitionQuery, fn func(models.ProjectRequisition) error) error {
+	seen := 0
+
+	for page := 1; ; page++ {
+		var res models.ProjectRequisitions
+		if err := getProjectRequisitionsPage(ctx, client, query.Range, page, query.PerPage, &res); err != nil {
+			return err
+		}
+
+		for _, req := range res.Requisitions {
+			if err := fn(req); err != nil {
+				if errors.Is(err, ErrStopPaging) {
+					return nil
+				}
+				return err
+			}
+
+			seen++
+			if query.MaxItems > 0 && seen >= query.MaxItems {
+				return nil
+			}
+		}
+
+		if lastPage(res.Meta, page, len(res.Requisitions)) {
+			return nil
+		}
+	}
+}
+
+// ListProjectRequisitions collects every requisition across all pages
+func ListProjectRequisitions(ctx context.Context, client *models.Client, query RequisitionQuery) ([]models.ProjectRequisition, error) {
+	var reqs []models.ProjectRequisition
+
+	err := EachProjectRequisition(ctx, client, query, func(req models.ProjectRequisition) error {
+		reqs = append(reqs, req)
+		return nil
+	})
+
+	return reqs, err
+}
+
+// reports whether page was the last one according to meta. an empty page
+// always ends the walk so a missing or wrong meta can't loop forever.
+func lastPage(meta models.Meta, page, count int) bool {
+	if count == 0 {
+		return true
+	}
+	if meta.PerPage <= 0 || meta.TotalEntries <= 0 {
+		return false
+	}
+	if meta.CurrentPage > 0 {
+		page = meta.CurrentPage
+	}
+	return page*meta.PerPage >= meta.TotalEntries
+}
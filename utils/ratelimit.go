@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is how the rate limiter tells time, tests can swap in a fake one
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RateLimiter is a token bucket meant to be shared by every goroutine
+// making requests through the same client
+type RateLimiter struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows rps requests per second on average with bursts of
+// up to burst requests. a burst below 1 is treated as 1.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return NewRateLimiterWithClock(rps, burst, realClock{})
+}
+
+// NewRateLimiterWithClock is NewRateLimiter with a custom clock
+func NewRateLimiterWithClock(rps float64, burst int, clock Clock) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		clock:  clock,
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-l.clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// takes a token, going into debt if there are none left, and returns how
+// long the caller has to wait for the debt to be paid back
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// hands back a token reserved by a caller that gave up waiting
+func (l *RateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
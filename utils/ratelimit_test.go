@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called, After channels fire once
+// the clock reaches their deadline
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), c: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = pending
+}
+
+func (c *fakeClock) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// blocks until n goroutines are waiting on the clock
+func waitForPending(t *testing.T, c *fakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d waiters on the clock, got %d", n, c.pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiterWithClock(1, 3, clock)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if n := clock.pending(); n != 0 {
+		t.Fatalf("requests within the burst waited on the clock %d times", n)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	waitForPending(t, clock, 1)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("request after the burst went through early: %v", err)
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request after the burst never went through")
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	tests := []struct {
+		name    string
+		rps     float64
+		burst   int
+		advance time.Duration
+		// waits of consecutive requests after advance
+		want []time.Duration
+	}{
+		{"no time passed", 2, 1, 0, []time.Duration{500 * time.Millisecond, time.Second}},
+		{"one token back", 2, 1, 500 * time.Millisecond, []time.Duration{0, 500 * time.Millisecond}},
+		{"half a token back", 4, 1, 125 * time.Millisecond, []time.Duration{125 * time.Millisecond}},
+		{"refill capped at burst", 1, 2, time.Minute, []time.Duration{0, 0, time.Second}},
+		{"burst below 1", 1, 0, 0, []time.Duration{time.Second}},
+		{"no limit", 0, 1, 0, []time.Duration{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := NewRateLimiterWithClock(tt.rps, tt.burst, clock)
+
+			// empty the bucket first, a burst below 1 still holds one token
+			for i := 0; i < tt.burst || i == 0; i++ {
+				l.reserve()
+			}
+
+			clock.Advance(tt.advance)
+			for i, want := range tt.want {
+				if got := l.reserve(); got != want {
+					t.Errorf("request %d waits %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterShared(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiterWithClock(10, 5, clock)
+
+	const n = 20
+	waits := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			waits[i] = l.reserve()
+		}(i)
+	}
+	wg.Wait()
+
+	// whichever goroutine gets there first, the burst goes out right away
+	// and the rest are spaced evenly at the rate
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	for i, got := range waits {
+		want := time.Duration(0)
+		if i >= 5 {
+			want = time.Duration(i-4) * 100 * time.Millisecond
+		}
+		if got != want {
+			t.Errorf("request %d waits %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiterWithClock(1, 1, clock)
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	waitForPending(t, clock, 1)
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait didn't return after its context was canceled")
+	}
+
+	// the canceled request gave its token back, so one second refills
+	// exactly one request
+	clock.Advance(time.Second)
+	if wait := l.reserve(); wait != 0 {
+		t.Fatalf("request after a canceled one waits %s, want 0", wait)
+	}
+}
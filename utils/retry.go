@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// jitter source, math/rand's global one isn't seeded for go 1.18 modules
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// methods that are safe to send twice
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodTrace:   true,
+}
+
+// statuses worth retrying, everything else is returned right away
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// decides whether attempt may be followed by another one and how long to
+// wait first. res or err is the outcome of the attempt that just finished.
+func nextRetry(ctx context.Context, policy models.RetryPolicy, method string, attempt int, started time.Time, res *http.Response, err error) (time.Duration, bool) {
+	if attempt >= policy.MaxAttempts {
+		return 0, false
+	}
+	if !idempotentMethods[method] && !policy.RetryUnsafe {
+		return 0, false
+	}
+
+	switch {
+	case err != nil:
+		// the caller gave up, don't try again
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+			return 0, false
+		}
+	case !retryableStatus(res.StatusCode):
+		return 0, false
+	}
+
+	wait := backoff(policy, attempt)
+	if res != nil {
+		if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			wait = after
+		}
+	}
+
+	if policy.MaxElapsed > 0 && time.Since(started)+wait > policy.MaxElapsed {
+		return 0, false
+	}
+
+	return wait, true
+}
+
+// full jitter exponential backoff, a random delay between 0 and
+// BaseDelay*2^(attempt-1) capped at MaxDelay
+func backoff(policy models.RetryPolicy, attempt int) time.Duration {
+	ceiling := policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || (policy.MaxDelay > 0 && ceiling > policy.MaxDelay) {
+		ceiling = policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return time.Duration(jitterRand.Int63n(int64(ceiling) + 1))
+}
+
+// parses a Retry-After header, which is either seconds or an http date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleeps for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
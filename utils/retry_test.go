@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := models.RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name    string
+		policy  models.RetryPolicy
+		attempt int
+		ceiling time.Duration
+	}{
+		{"first retry", policy, 1, 100 * time.Millisecond},
+		{"doubles", policy, 3, 400 * time.Millisecond},
+		{"capped at max delay", policy, 6, time.Second},
+		{"overflow is capped", policy, 70, time.Second},
+		{"no max delay", models.RetryPolicy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"no delays", models.RetryPolicy{}, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 200; i++ {
+				if got := backoff(tt.policy, tt.attempt); got < 0 || got > tt.ceiling {
+					t.Fatalf("backoff %s outside [0, %s]", got, tt.ceiling)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNextRetry(t *testing.T) {
+	policy := models.RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	response := func(code int, retryAfter string) *http.Response {
+		res := &http.Response{StatusCode: code, Header: http.Header{}}
+		if retryAfter != "" {
+			res.Header.Set("Retry-After", retryAfter)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		policy  models.RetryPolicy
+		method  string
+		attempt int
+		started time.Time
+		res     *http.Response
+		err     error
+		retry   bool
+		// exact wait expected, otherwise anything up to the backoff ceiling
+		wait time.Duration
+	}{
+		{name: "503 is retried", policy: policy, method: http.MethodGet, attempt: 1, res: response(503, ""), retry: true},
+		{name: "429 is retried", policy: policy, method: http.MethodGet, attempt: 1, res: response(429, ""), retry: true},
+		{name: "transport error is retried", policy: policy, method: http.MethodGet, attempt: 1, err: errors.New("connection reset"), retry: true},
+		{name: "404 is not retried", policy: policy, method: http.MethodGet, attempt: 1, res: response(404, "")},
+		{name: "500 is not retried", policy: policy, method: http.MethodGet, attempt: 1, res: response(500, "")},
+		{name: "out of attempts", policy: policy, method: http.MethodGet, attempt: 3, res: response(503, "")},
+		{name: "post is not retried", policy: policy, method: http.MethodPost, attempt: 1, res: response(503, "")},
+		{name: "post with retry unsafe", policy: models.RetryPolicy{MaxAttempts: 3, RetryUnsafe: true}, method: http.MethodPost, attempt: 1, res: response(503, ""), retry: true},
+		{name: "retry-after wins over backoff", policy: policy, method: http.MethodGet, attempt: 1, res: response(429, "2"), retry: true, wait: 2 * time.Second},
+		{name: "retry-after past the deadline", policy: models.RetryPolicy{MaxAttempts: 3, MaxElapsed: time.Second}, method: http.MethodGet, attempt: 1, res: response(429, "5")},
+		{name: "deadline already passed", policy: models.RetryPolicy{MaxAttempts: 3, MaxElapsed: time.Second}, method: http.MethodGet, attempt: 1, started: time.Now().Add(-2 * time.Second), res: response(503, "")},
+		{name: "canceled context", ctx: canceled, policy: policy, method: http.MethodGet, attempt: 1, err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			started := tt.started
+			if started.IsZero() {
+				started = time.Now()
+			}
+
+			wait, retry := nextRetry(ctx, tt.policy, tt.method, tt.attempt, started, tt.res, tt.err)
+			if retry != tt.retry {
+				t.Fatalf("retry = %v, want %v", retry, tt.retry)
+			}
+			if !retry {
+				return
+			}
+
+			if tt.wait > 0 {
+				if wait != tt.wait {
+					t.Errorf("wait = %s, want %s", wait, tt.wait)
+				}
+			} else if ceiling := tt.policy.BaseDelay << (tt.attempt - 1); wait < 0 || wait > ceiling {
+				t.Errorf("wait = %s, want up to %s", wait, ceiling)
+			}
+		})
+	}
+}
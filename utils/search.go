@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// PatientQuery filters a patient search, empty fields are left out
+type PatientQuery struct {
+	FirstName   string
+	LastName    string
+	DateOfBirth time.Time
+}
+
+// ProviderQuery filters a provider search, empty fields are left out
+type ProviderQuery struct {
+	FirstName string
+	LastName  string
+	NPI       string
+}
+
+// SearchPatients finds the organization's patients matching every field
+// of the query, each with the requisitions they're on
+func SearchPatients(ctx context.Context, client *models.Client, q PatientQuery, target interface{}) error {
+	query := url.Values{}
+	query.Set("organizationId", client.OrganizationId.String())
+	setIfNotEmpty(query, "first_name", q.FirstName)
+	setIfNotEmpty(query, "last_name", q.LastName)
+	if !q.DateOfBirth.IsZero() {
+		query.Set("date_of_birth", q.DateOfBirth.Format("2006-01-02"))
+	}
+
+	return do(ctx, client, http.MethodGet, client.URL("/patients?"+query.Encode()), nil, target)
+}
+
+// SearchProviders finds the organization's ordering providers matching
+// every field of the query, each with the requisitions they ordered
+func SearchProviders(ctx context.Context, client *models.Client, q ProviderQuery, target interface{}) error {
+	query := url.Values{}
+	query.Set("organizationId", client.OrganizationId.String())
+	setIfNotEmpty(query, "first_name", q.FirstName)
+	setIfNotEmpty(query, "last_name", q.LastName)
+	setIfNotEmpty(query, "npi", q.NPI)
+
+	return do(ctx, client, http.MethodGet, client.URL("/providers?"+query.Encode()), nil, target)
+}
+
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		query.Set(key, value)
+	}
+}
+
+// MaskPatient hides a patient's phi for display. names keep their first
+// letter so results can still be told apart, everything else is redacted.
+func MaskPatient(p models.Patient) models.Patient {
+	p.FirstName = maskName(p.FirstName)
+	p.LastName = maskName(p.LastName)
+	p.DateOfBirth = maskValue(p.DateOfBirth)
+	p.Sex = maskValue(p.Sex)
+	p.MRN = maskValue(p.MRN)
+	p.Extra = maskExtra(p.Extra)
+	return p
+}
+
+// MaskRequisition hides the phi on a requisition for display, the patient
+// is masked like MaskPatient and phi keys in the other fields are redacted
+func MaskRequisition(r models.Requisition) models.Requisition {
+	if r.Patient != nil {
+		p := MaskPatient(*r.Patient)
+		r.Patient = &p
+	}
+	r.Extra = maskExtra(r.Extra)
+
+	if r.CustomAttributes != nil {
+		b, err := json.Marshal(r.CustomAttributes)
+		var attrs map[string]any
+		if err == nil && json.Unmarshal(redactBody(b), &attrs) == nil {
+			r.CustomAttributes = attrs
+		}
+	}
+	return r
+}
+
+// IsPHIPath reports whether a dotted field path like patient.first_name
+// holds phi, everything under patient counts
+func IsPHIPath(path string) bool {
+	parts := strings.Split(path, ".")
+	if parts[0] == "patient" {
+		return true
+	}
+	for _, p := range parts {
+		if isPHIKey(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskName(s string) string {
+	if s == "" {
+		return ""
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return string(r) + "***"
+}
+
+func maskValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redacts phi keys anywhere in the unknown fields
+func maskExtra(extra models.Extra) models.Extra {
+	if extra == nil {
+		return nil
+	}
+
+	out := make(models.Extra, len(extra))
+	for k, raw := range extra {
+		if isPHIKey(k) {
+			raw, _ = json.Marshal(redacted)
+		} else {
+			raw = redactBody(raw)
+		}
+		out[k] = raw
+	}
+	return out
+}
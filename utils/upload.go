@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sabino-ramirez/oah/models"
+)
+
+// ContentType guesses a file's type from its extension, falling back to
+// sniffing its first bytes
+func ContentType(f *os.File) (string, error) {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(f.Name()))); t != "" {
+		return t, nil
+	}
+
+	head := make([]byte, 512)
+	n, err := f.Read(head)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// UploadAttachment attaches a file to a requisition as multipart form
+// data. the file is streamed from open rather than read into memory, open
+// is called for every attempt and has to start from the beginning of the
+// file. size must be its exact length so the request has a Content-Length.
+// uploads are only retried with RetryUnsafe, like any other post.
+func UploadAttachment(ctx context.Context, client *models.Client, identifier, fileName, contentType string, open func() (io.Reader, error), size int64, target interface{}) error {
+	reqURL := client.URL("/requisitions/" + url.PathEscape(identifier) + "/attachments")
+
+	// the multipart framing is small, only the file itself is streamed
+	var head bytes.Buffer
+	mw := multipart.NewWriter(&head)
+
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": fileName}))
+	partHeader.Set("Content-Type", contentType)
+	if _, err := mw.CreatePart(partHeader); err != nil {
+		return err
+	}
+
+	// what mw.Close would write after the file
+	closing := []byte("\r\n--" + mw.Boundary() + "--\r\n")
+
+	newBody := func() (io.Reader, int64, error) {
+		r, err := open()
+		if err != nil {
+			return nil, 0, err
+		}
+		body := io.MultiReader(bytes.NewReader(head.Bytes()), r, bytes.NewReader(closing))
+		return body, int64(head.Len()) + size + int64(len(closing)), nil
+	}
+
+	res, err := sendBody(ctx, client, http.MethodPost, reqURL, newBody, http.Header{"Content-Type": {mw.FormDataContentType()}})
+	if err != nil {
+		return err
+	}
+	return decode(res, http.MethodPost, reqURL, target)
+}